@@ -1,16 +1,348 @@
 package class
 
-type AccessFlags = byte
+import (
+	"fmt"
+	"strings"
+)
+
+// AccessFlags is the access_flags item of a ClassFile structure.
+type AccessFlags uint16
 
 // https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1-200-E.1
 const (
 	AccessFlagsPublic     AccessFlags = 0x0001
-	AccessFlagsFinal                  = 0x0010
-	AccessFlagsSuper                  = 0x0020
-	AccessFlagsInterface              = 0x0200
-	AccessFlagsAbstract               = 0x0400
-	AccessFlagsSynthetic              = 0x1000
-	AccessFlagsAnnotation             = 0x2000
-	AccessFlagsEnum                   = 0x4000
-	AccessFlagsModule                 = 0x8000
+	AccessFlagsFinal      AccessFlags = 0x0010
+	AccessFlagsSuper      AccessFlags = 0x0020
+	AccessFlagsInterface  AccessFlags = 0x0200
+	AccessFlagsAbstract   AccessFlags = 0x0400
+	AccessFlagsSynthetic  AccessFlags = 0x1000
+	AccessFlagsAnnotation AccessFlags = 0x2000
+	AccessFlagsEnum       AccessFlags = 0x4000
+	AccessFlagsModule     AccessFlags = 0x8000
+)
+
+// FieldAccessFlags is the access_flags item of a field_info structure.
+type FieldAccessFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.5-200-A.1
+const (
+	FieldAccessFlagsPublic    FieldAccessFlags = 0x0001
+	FieldAccessFlagsPrivate   FieldAccessFlags = 0x0002
+	FieldAccessFlagsProtected FieldAccessFlags = 0x0004
+	FieldAccessFlagsStatic    FieldAccessFlags = 0x0008
+	FieldAccessFlagsFinal     FieldAccessFlags = 0x0010
+	FieldAccessFlagsVolatile  FieldAccessFlags = 0x0040
+	FieldAccessFlagsTransient FieldAccessFlags = 0x0080
+	FieldAccessFlagsSynthetic FieldAccessFlags = 0x1000
+	FieldAccessFlagsEnum      FieldAccessFlags = 0x4000
+)
+
+// MethodAccessFlags is the access_flags item of a method_info structure.
+type MethodAccessFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.6-200-A.1
+const (
+	MethodAccessFlagsPublic       MethodAccessFlags = 0x0001
+	MethodAccessFlagsPrivate      MethodAccessFlags = 0x0002
+	MethodAccessFlagsProtected    MethodAccessFlags = 0x0004
+	MethodAccessFlagsStatic       MethodAccessFlags = 0x0008
+	MethodAccessFlagsFinal        MethodAccessFlags = 0x0010
+	MethodAccessFlagsSynchronized MethodAccessFlags = 0x0020
+	MethodAccessFlagsBridge       MethodAccessFlags = 0x0040
+	MethodAccessFlagsVarargs      MethodAccessFlags = 0x0080
+	MethodAccessFlagsNative       MethodAccessFlags = 0x0100
+	MethodAccessFlagsAbstract     MethodAccessFlags = 0x0400
+	MethodAccessFlagsStrict       MethodAccessFlags = 0x0800
+	MethodAccessFlagsSynthetic    MethodAccessFlags = 0x1000
+)
+
+// InnerClassAccessFlags is the inner_class_access_flags item of an entry of the InnerClasses attribute.
+type InnerClassAccessFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.6-300-D.1-D.1
+const (
+	InnerClassAccessFlagsPublic     InnerClassAccessFlags = 0x0001
+	InnerClassAccessFlagsPrivate    InnerClassAccessFlags = 0x0002
+	InnerClassAccessFlagsProtected  InnerClassAccessFlags = 0x0004
+	InnerClassAccessFlagsStatic     InnerClassAccessFlags = 0x0008
+	InnerClassAccessFlagsFinal      InnerClassAccessFlags = 0x0010
+	InnerClassAccessFlagsInterface  InnerClassAccessFlags = 0x0200
+	InnerClassAccessFlagsAbstract   InnerClassAccessFlags = 0x0400
+	InnerClassAccessFlagsSynthetic  InnerClassAccessFlags = 0x1000
+	InnerClassAccessFlagsAnnotation InnerClassAccessFlags = 0x2000
+	InnerClassAccessFlagsEnum       InnerClassAccessFlags = 0x4000
 )
+
+// ParameterAccessFlags is the access_flags item of an entry of the MethodParameters attribute.
+type ParameterAccessFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.24
+const (
+	ParameterAccessFlagsFinal     ParameterAccessFlags = 0x0010
+	ParameterAccessFlagsSynthetic ParameterAccessFlags = 0x1000
+	ParameterAccessFlagsMandated  ParameterAccessFlags = 0x8000
+)
+
+// ModuleFlags is the module_flags item of the Module attribute.
+type ModuleFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.25
+const (
+	ModuleFlagsOpen      ModuleFlags = 0x0020
+	ModuleFlagsSynthetic ModuleFlags = 0x1000
+	ModuleFlagsMandated  ModuleFlags = 0x8000
+)
+
+// RequiresFlags is the requires_flags item of an entry of the requires table of the Module attribute.
+type RequiresFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.25
+const (
+	RequiresFlagsTransitive  RequiresFlags = 0x0020
+	RequiresFlagsStaticPhase RequiresFlags = 0x0040
+	RequiresFlagsSynthetic   RequiresFlags = 0x1000
+	RequiresFlagsMandated    RequiresFlags = 0x8000
+)
+
+// ExportsFlags is the exports_flags item of an entry of the exports table of the Module attribute,
+// and also the opens_flags item of the opens table, which have the same flags.
+type ExportsFlags uint16
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.25
+const (
+	ExportsFlagsSynthetic ExportsFlags = 0x1000
+	ExportsFlagsMandated  ExportsFlags = 0x8000
+)
+
+type flagModifier[T ~uint16] struct {
+	flag     T
+	modifier string
+}
+
+// modifiers returns the Java modifiers of the flags in the order of the table, such as "public static final".
+// The flags with no modifier, such as ACC_SYNTHETIC, are omitted.
+func modifiers[T ~uint16](flags T, table []flagModifier[T]) string {
+	var ms []string
+	for _, m := range table {
+		if flags&m.flag != 0 {
+			ms = append(ms, m.modifier)
+		}
+	}
+	return strings.Join(ms, " ")
+}
+
+// String returns the Java modifiers of the class, such as "public final".
+// ACC_ABSTRACT is omitted for interfaces as javap does.
+func (f AccessFlags) String() string {
+	if f&AccessFlagsInterface != 0 {
+		f &^= AccessFlagsAbstract
+	}
+	return modifiers(f, []flagModifier[AccessFlags]{
+		{AccessFlagsPublic, "public"},
+		{AccessFlagsAbstract, "abstract"},
+		{AccessFlagsFinal, "final"},
+	})
+}
+
+// String returns the Java modifiers of the field, such as "public static final".
+func (f FieldAccessFlags) String() string {
+	return modifiers(f, []flagModifier[FieldAccessFlags]{
+		{FieldAccessFlagsPublic, "public"},
+		{FieldAccessFlagsProtected, "protected"},
+		{FieldAccessFlagsPrivate, "private"},
+		{FieldAccessFlagsStatic, "static"},
+		{FieldAccessFlagsFinal, "final"},
+		{FieldAccessFlagsTransient, "transient"},
+		{FieldAccessFlagsVolatile, "volatile"},
+	})
+}
+
+// String returns the Java modifiers of the method, such as "public static synchronized".
+func (f MethodAccessFlags) String() string {
+	return modifiers(f, []flagModifier[MethodAccessFlags]{
+		{MethodAccessFlagsPublic, "public"},
+		{MethodAccessFlagsProtected, "protected"},
+		{MethodAccessFlagsPrivate, "private"},
+		{MethodAccessFlagsAbstract, "abstract"},
+		{MethodAccessFlagsStatic, "static"},
+		{MethodAccessFlagsFinal, "final"},
+		{MethodAccessFlagsSynchronized, "synchronized"},
+		{MethodAccessFlagsNative, "native"},
+		{MethodAccessFlagsStrict, "strictfp"},
+	})
+}
+
+// String returns the Java modifiers of the nested class, such as "public static final".
+// ACC_ABSTRACT is omitted for interfaces as javap does.
+func (f InnerClassAccessFlags) String() string {
+	if f&InnerClassAccessFlagsInterface != 0 {
+		f &^= InnerClassAccessFlagsAbstract
+	}
+	return modifiers(f, []flagModifier[InnerClassAccessFlags]{
+		{InnerClassAccessFlagsPublic, "public"},
+		{InnerClassAccessFlagsProtected, "protected"},
+		{InnerClassAccessFlagsPrivate, "private"},
+		{InnerClassAccessFlagsAbstract, "abstract"},
+		{InnerClassAccessFlagsStatic, "static"},
+		{InnerClassAccessFlagsFinal, "final"},
+	})
+}
+
+// String returns the Java modifiers of the formal parameter, which is "final" or empty.
+func (f ParameterAccessFlags) String() string {
+	return modifiers(f, []flagModifier[ParameterAccessFlags]{
+		{ParameterAccessFlagsFinal, "final"},
+	})
+}
+
+// String returns the Java modifiers of the module, which is "open" or empty.
+func (f ModuleFlags) String() string {
+	return modifiers(f, []flagModifier[ModuleFlags]{
+		{ModuleFlagsOpen, "open"},
+	})
+}
+
+// String returns the Java modifiers of the dependence, such as "transitive static".
+func (f RequiresFlags) String() string {
+	return modifiers(f, []flagModifier[RequiresFlags]{
+		{RequiresFlagsTransitive, "transitive"},
+		{RequiresFlagsStaticPhase, "static"},
+	})
+}
+
+// String returns the Java modifiers of the exported or opened package, which is always empty
+// since ACC_SYNTHETIC and ACC_MANDATED have no modifier.
+func (f ExportsFlags) String() string {
+	return ""
+}
+
+// classAccessFlags validates the access_flags item of a ClassFile structure.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1
+func classAccessFlags() validator[AccessFlags] {
+	return classAccessFlagsValidator{}
+}
+
+type classAccessFlagsValidator struct{}
+
+func (v classAccessFlagsValidator) validate(f AccessFlags, name string) error {
+	switch {
+	case f&AccessFlagsModule != 0:
+		if f != AccessFlagsModule {
+			return accessFlagsError("no other flag with ACC_MODULE", f)
+		}
+	case f&AccessFlagsInterface != 0:
+		if f&AccessFlagsAbstract == 0 {
+			return accessFlagsError("ACC_ABSTRACT with ACC_INTERFACE", f)
+		}
+		if f&(AccessFlagsFinal|AccessFlagsSuper|AccessFlagsEnum) != 0 {
+			return accessFlagsError("no ACC_FINAL, ACC_SUPER or ACC_ENUM with ACC_INTERFACE", f)
+		}
+	default:
+		if f&AccessFlagsAnnotation != 0 {
+			return accessFlagsError("no ACC_ANNOTATION without ACC_INTERFACE", f)
+		}
+		if f&AccessFlagsFinal != 0 && f&AccessFlagsAbstract != 0 {
+			return accessFlagsError("not both ACC_FINAL and ACC_ABSTRACT", f)
+		}
+	}
+	return nil
+}
+
+// fieldAccessFlags validates the access_flags item of a field_info structure.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.5
+func fieldAccessFlags(cf *ClassFile) validator[FieldAccessFlags] {
+	return fieldAccessFlagsValidator{cf: cf}
+}
+
+type fieldAccessFlagsValidator struct {
+	cf *ClassFile
+}
+
+func (v fieldAccessFlagsValidator) validate(f FieldAccessFlags, name string) error {
+	if 1 < countFlags(f, FieldAccessFlagsPublic, FieldAccessFlagsPrivate, FieldAccessFlagsProtected) {
+		return accessFlagsError("at most one of ACC_PUBLIC, ACC_PRIVATE and ACC_PROTECTED", f)
+	}
+	if f&FieldAccessFlagsFinal != 0 && f&FieldAccessFlagsVolatile != 0 {
+		return accessFlagsError("not both ACC_FINAL and ACC_VOLATILE", f)
+	}
+	if v.cf.AccessFlags&AccessFlagsInterface != 0 {
+		required := FieldAccessFlagsPublic | FieldAccessFlagsStatic | FieldAccessFlagsFinal
+		if f&required != required || f&^(required|FieldAccessFlagsSynthetic) != 0 {
+			return accessFlagsError("ACC_PUBLIC, ACC_STATIC and ACC_FINAL, and only ACC_SYNTHETIC in addition for an interface field", f)
+		}
+	}
+	return nil
+}
+
+// methodAccessFlags validates the access_flags item of a method_info structure with the name of the method.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.6
+func methodAccessFlags(cf *ClassFile, methodName string) validator[MethodAccessFlags] {
+	return &methodAccessFlagsValidator{cf: cf, methodName: methodName}
+}
+
+type methodAccessFlagsValidator struct {
+	cf         *ClassFile
+	methodName string
+}
+
+func (v *methodAccessFlagsValidator) validate(f MethodAccessFlags, name string) error {
+	// other flags than ACC_STATIC and ACC_STRICT of class initialization methods are ignored
+	if v.methodName == "<clinit>" {
+		return nil
+	}
+
+	if 1 < countFlags(f, MethodAccessFlagsPublic, MethodAccessFlagsPrivate, MethodAccessFlagsProtected) {
+		return accessFlagsError("at most one of ACC_PUBLIC, ACC_PRIVATE and ACC_PROTECTED", f)
+	}
+
+	if v.cf.AccessFlags&AccessFlagsInterface != 0 {
+		if f&(MethodAccessFlagsProtected|MethodAccessFlagsFinal|MethodAccessFlagsSynchronized|MethodAccessFlagsNative) != 0 {
+			return accessFlagsError("no ACC_PROTECTED, ACC_FINAL, ACC_SYNCHRONIZED or ACC_NATIVE for an interface method", f)
+		}
+		if v.cf.MajorVer < 52 {
+			required := MethodAccessFlagsPublic | MethodAccessFlagsAbstract
+			optional := MethodAccessFlagsVarargs | MethodAccessFlagsBridge | MethodAccessFlagsSynthetic
+			if f&required != required || f&^(required|optional) != 0 {
+				return accessFlagsError("ACC_PUBLIC and ACC_ABSTRACT, and only ACC_VARARGS, ACC_BRIDGE or ACC_SYNTHETIC in addition for an interface method before version 52.0", f)
+			}
+		} else if countFlags(f, MethodAccessFlagsPublic, MethodAccessFlagsPrivate) != 1 {
+			return accessFlagsError("exactly one of ACC_PUBLIC and ACC_PRIVATE for an interface method", f)
+		}
+	}
+
+	if f&MethodAccessFlagsAbstract != 0 {
+		if f&(MethodAccessFlagsPrivate|MethodAccessFlagsStatic|MethodAccessFlagsFinal|MethodAccessFlagsSynchronized|MethodAccessFlagsNative) != 0 {
+			return accessFlagsError("no ACC_PRIVATE, ACC_STATIC, ACC_FINAL, ACC_SYNCHRONIZED or ACC_NATIVE with ACC_ABSTRACT", f)
+		}
+		if 46 <= v.cf.MajorVer && v.cf.MajorVer <= 60 && f&MethodAccessFlagsStrict != 0 {
+			return accessFlagsError("no ACC_STRICT with ACC_ABSTRACT", f)
+		}
+	}
+
+	if v.methodName == "<init>" {
+		allowed := MethodAccessFlagsPublic | MethodAccessFlagsPrivate | MethodAccessFlagsProtected |
+			MethodAccessFlagsVarargs | MethodAccessFlagsStrict | MethodAccessFlagsSynthetic
+		if f&^allowed != 0 {
+			return accessFlagsError("only ACC_PUBLIC, ACC_PRIVATE, ACC_PROTECTED, ACC_VARARGS, ACC_STRICT or ACC_SYNTHETIC for <init>", f)
+		}
+	}
+	return nil
+}
+
+// accessFlagsError returns the error of the access flags which do not meet the expected combination.
+func accessFlagsError[T ~uint16](expected string, f T) error {
+	return valueError(ErrInvalidAccessFlags, expected, fmt.Sprintf("0x%04x", uint16(f)))
+}
+
+func countFlags[T ~uint16](f T, flags ...T) int {
+	n := 0
+	for _, flag := range flags {
+		if f&flag != 0 {
+			n++
+		}
+	}
+	return n
+}
@@ -6,6 +6,8 @@ type annotation struct {
 	typeIndex            uint16
 	numElementValuePairs uint16
 	elementValuePairs    []elementValuePair
+
+	span Span
 }
 
 type elementValuePair struct {
@@ -15,17 +17,18 @@ type elementValuePair struct {
 
 func parseAnnotation(er *errReader, cf *ClassFile) annotation {
 	var a annotation
-	if item(er, "type_index", integer(&a.typeIndex, constantPoolStructure[uint16, *constantUtf8](cf))) {
-		//TODO field descriptor
-	}
+	offset := er.r.n
+	item(er, "type_index", integer(&a.typeIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), fieldDescriptor(cf)))
 	if item(er, "num_element_value_pairs", integer(&a.numElementValuePairs)) {
-		a.elementValuePairs = make([]elementValuePair, a.numElementValuePairs)
-		for i := 0; i < int(a.numElementValuePairs); i++ {
-			p := parent(er, fmt.Sprintf("element_value_pairs[%d]", i))
-			child(p, "element_name_index", integer(&a.elementValuePairs[i].elementNameIndex, constantPoolStructure[uint16, *constantUtf8](cf)))
-			a.elementValuePairs[i].value = parseElementValue(er, cf)
-		}
+		a.elementValuePairs = makeTable[elementValuePair](er, cf, int(a.numElementValuePairs))
+		item(er, "element_value_pairs", entries(a.elementValuePairs, func(er *errReader) elementValuePair {
+			var p elementValuePair
+			item(er, "element_name_index", integer(&p.elementNameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf)))
+			p.value = parseElementValue(er, cf)
+			return p
+		}))
 	}
+	a.span = spanFrom(er, offset)
 	return a
 }
 
@@ -36,48 +39,61 @@ type elementValue struct {
 
 func parseElementValue(er *errReader, cf *ClassFile) elementValue {
 	var v elementValue
+	// element_value structures nest through annotations and arrays
+	er.depth++
+	defer func() { er.depth-- }()
+	if max := cf.options.Limits.maxDepth(); er.err == nil && max < er.depth {
+		er.name = "element_value"
+		er.fail(fmt.Errorf("%w: element_value nests deeper than %d", ErrLimitExceeded, max))
+		return v
+	}
 	item(er, "element_value.tag", integer(&v.tag))
 
 	switch rune(v.tag) {
 	case 'B', 'C', 'I', 'S', 'Z':
 		var i uint16
-		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *constantInteger](cf)))
+		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *ConstantInteger](cf)))
 		v.value = elementValueConstValueIndex(i)
 	case 'D':
 		var i uint16
-		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *constantDouble](cf)))
+		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *ConstantDouble](cf)))
 		v.value = elementValueConstValueIndex(i)
 	case 'F':
 		var i uint16
-		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *constantFloat](cf)))
+		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *ConstantFloat](cf)))
 		v.value = elementValueConstValueIndex(i)
 	case 'J':
 		var i uint16
-		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *constantLong](cf)))
+		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *ConstantLong](cf)))
 		v.value = elementValueConstValueIndex(i)
 	case 's':
 		var i uint16
-		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *constantString](cf)))
+		item(er, "const_value_index", integer(&i, constantPoolStructure[uint16, *ConstantUtf8](cf)))
 		v.value = elementValueConstValueIndex(i)
 	case 'e':
 		var e elementValueEnumConstValue
-		item(er, "enum_const_value.type_name_index", integer(&e.typeNameIndex, constantPoolStructure[uint16, *constantString](cf)))
-		item(er, "enum_const_value.const_name_index", integer(&e.constNameIndex, constantPoolStructure[uint16, *constantString](cf)))
+		item(er, "enum_const_value.type_name_index", integer(&e.typeNameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), fieldDescriptor(cf)))
+		item(er, "enum_const_value.const_name_index", integer(&e.constNameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf)))
 		v.value = &e
 	case 'c':
 		var i uint16
-		item(er, "class_info_index", integer(&i, constantPoolStructure[uint16, *constantString](cf)))
+		item(er, "class_info_index", integer(&i, constantPoolStructure[uint16, *ConstantUtf8](cf), returnDescriptor(cf)))
 		v.value = elementValueClassInfoIndex(i)
 	case '@':
 		v.value = elementValueAnnotationValue(parseAnnotation(er, cf))
 	case '[':
 		var a elementValueArrayValue
 		if item(er, "array_value.num_values", integer(&a.numValues)) {
-			a.values = make([]elementValue, a.numValues)
+			a.values = makeTable[elementValue](er, cf, int(a.numValues))
 			item(er, "array_value.values", entries(a.values, func(e *errReader) elementValue {
 				return parseElementValue(e, cf)
 			}))
 		}
+		v.value = &a
+	default:
+		if er.err == nil {
+			er.fail(fmt.Errorf("invalid element_value.tag(%d)", v.tag))
+		}
 	}
 	return v
 }
@@ -103,3 +119,387 @@ func (*elementValueEnumConstValue) _elementValueItem() {}
 func (elementValueClassInfoIndex) _elementValueItem()  {}
 func (elementValueAnnotationValue) _elementValueItem() {}
 func (*elementValueArrayValue) _elementValueItem()     {}
+
+// Annotation is an annotation on a declaration, whose element values are converted to Go values.
+type Annotation struct {
+	// Type is the field descriptor of the annotation interface, such as "Ljava/lang/Deprecated;".
+	Type string
+	// Elements are the element-value pairs in the order they appear in the class file.
+	// The elements omitted in the source code take their default values, which are not recorded here.
+	Elements []AnnotationElement
+	// Visible reports whether the annotation is recorded in a RuntimeVisible*Annotations attribute.
+	Visible bool
+	// Span is the bytes of the annotation structure.
+	Span Span
+}
+
+// AnnotationElement is an element-value pair of an annotation.
+//
+// Value is one of the following by the tag of the element_value:
+//   - int8, uint16, int32, int16 or bool for 'B', 'C', 'I', 'S' and 'Z'
+//   - int64, float32 or float64 for 'J', 'F' and 'D'
+//   - string for 's'
+//   - EnumConstant for 'e'
+//   - ClassDescriptor for 'c'
+//   - Annotation for '@'
+//   - []any of these values for '['
+type AnnotationElement struct {
+	Name  string
+	Value any
+}
+
+// EnumConstant is an enum constant as an element value.
+type EnumConstant struct {
+	// Type is the field descriptor of the enum class, such as "Ljava/lang/annotation/RetentionPolicy;".
+	Type string
+	Name string
+}
+
+// ClassDescriptor is a class literal as an element value, which is the return descriptor
+// of the class such as "Ljava/lang/Object;", "[I" or "V" for void.class.
+type ClassDescriptor string
+
+// Value returns the value of the element of the name.
+func (a *Annotation) Value(name string) (v any, ok bool) {
+	for _, e := range a.Elements {
+		if e.Name == name {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+func resolveAnnotation(cf *ClassFile, a annotation, visible bool) Annotation {
+	an := Annotation{
+		Type:    cf.utf8(a.typeIndex),
+		Visible: visible,
+		Span:    a.span,
+	}
+	if 0 < len(a.elementValuePairs) {
+		an.Elements = make([]AnnotationElement, len(a.elementValuePairs))
+		for i, p := range a.elementValuePairs {
+			an.Elements[i] = AnnotationElement{
+				Name:  cf.utf8(p.elementNameIndex),
+				Value: resolveElementValue(cf, p.value, visible),
+			}
+		}
+	}
+	return an
+}
+
+func resolveElementValue(cf *ClassFile, v elementValue, visible bool) any {
+	switch e := v.value.(type) {
+	case elementValueConstValueIndex:
+		// the constant does not match the tag in a ClassFile parsed in lenient mode
+		c, ok := cf.lookupConstantPool(uint16(e))
+		if !ok {
+			return nil
+		}
+		switch c := c.(type) {
+		case *ConstantInteger:
+			switch v.tag {
+			case 'B':
+				return int8(c.Int32())
+			case 'C':
+				return uint16(c.Int32())
+			case 'I':
+				return c.Int32()
+			case 'S':
+				return int16(c.Int32())
+			case 'Z':
+				return booleanValue(c.Int32())
+			}
+		case *ConstantLong:
+			if v.tag == 'J' {
+				return c.Int64()
+			}
+		case *ConstantFloat:
+			if v.tag == 'F' {
+				return c.Float32()
+			}
+		case *ConstantDouble:
+			if v.tag == 'D' {
+				return c.Float64()
+			}
+		case *ConstantUtf8:
+			if v.tag == 's' {
+				return c.String()
+			}
+		}
+	case *elementValueEnumConstValue:
+		return EnumConstant{
+			Type: cf.utf8(e.typeNameIndex),
+			Name: cf.utf8(e.constNameIndex),
+		}
+	case elementValueClassInfoIndex:
+		return ClassDescriptor(cf.utf8(uint16(e)))
+	case elementValueAnnotationValue:
+		return resolveAnnotation(cf, annotation(e), visible)
+	case *elementValueArrayValue:
+		vs := make([]any, len(e.values))
+		for i, ev := range e.values {
+			vs[i] = resolveElementValue(cf, ev, visible)
+		}
+		return vs
+	}
+	return nil
+}
+
+// annotations returns the annotations in the RuntimeVisibleAnnotations and RuntimeInvisibleAnnotations attributes.
+func annotations(cf *ClassFile, attrs []attributeInfo) []Annotation {
+	var as []Annotation
+	for _, a := range attrs {
+		switch attr := resolveAttributeNamed(a, "RuntimeVisibleAnnotations", "RuntimeInvisibleAnnotations").(type) {
+		case *attributeRuntimeVisibleAnnotations:
+			for _, an := range attr.annotations {
+				as = append(as, resolveAnnotation(cf, an, true))
+			}
+		case *attributeRuntimeInvisibleAnnotations:
+			for _, an := range attr.annotations {
+				as = append(as, resolveAnnotation(cf, an, false))
+			}
+		}
+	}
+	return as
+}
+
+// findAnnotation returns the first annotation of the type, converting only the found one.
+func findAnnotation(cf *ClassFile, attrs []attributeInfo, typ string) (a Annotation, ok bool) {
+	for _, attr := range attrs {
+		var as []annotation
+		var visible bool
+		switch attr := resolveAttributeNamed(attr, "RuntimeVisibleAnnotations", "RuntimeInvisibleAnnotations").(type) {
+		case *attributeRuntimeVisibleAnnotations:
+			as, visible = attr.annotations, true
+		case *attributeRuntimeInvisibleAnnotations:
+			as = attr.annotations
+		}
+		for _, an := range as {
+			if cf.utf8(an.typeIndex) == typ {
+				return resolveAnnotation(cf, an, visible), true
+			}
+		}
+	}
+	return a, false
+}
+
+func annotationValue(cf *ClassFile, attrs []attributeInfo, typ, name string) (any, bool) {
+	a, ok := findAnnotation(cf, attrs, typ)
+	if !ok {
+		return nil, false
+	}
+	return a.Value(name)
+}
+
+type typeAnnotation struct {
+	targetType TargetType
+	targetInfo TypeAnnotationTarget
+	targetPath []TypePathEntry
+	annotation
+
+	// span of the whole type_annotation, while annotation.span covers only its annotation part
+	span Span
+}
+
+func parseTypeAnnotation(er *errReader, cf *ClassFile, targets []TargetType) typeAnnotation {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.20
+	var a typeAnnotation
+	offset := er.r.n
+	item(er, "target_type", integer(&a.targetType, oneOf(targets...)))
+
+	t := &a.targetInfo
+	switch a.targetType {
+	case TargetClassTypeParameter, TargetMethodTypeParameter:
+		item(er, "type_parameter_target.type_parameter_index", integer(&t.TypeParameterIndex))
+	case TargetClassExtends:
+		item(er, "supertype_target.supertype_index", integer(&t.SupertypeIndex))
+	case TargetClassTypeParameterBound, TargetMethodTypeParameterBound:
+		item(er, "type_parameter_bound_target.type_parameter_index", integer(&t.TypeParameterIndex))
+		item(er, "type_parameter_bound_target.bound_index", integer(&t.BoundIndex))
+	case TargetField, TargetMethodReturn, TargetMethodReceiver:
+		// empty_target
+	case TargetMethodFormalParameter:
+		item(er, "formal_parameter_target.formal_parameter_index", integer(&t.FormalParameterIndex))
+	case TargetThrows:
+		item(er, "throws_target.throws_type_index", integer(&t.ThrowsTypeIndex))
+	case TargetLocalVariable, TargetResourceVariable:
+		var n uint16
+		if item(er, "localvar_target.table_length", integer(&n)) {
+			t.LocalVariables = makeTable[LocalVariableTarget](er, cf, int(n))
+			item(er, "localvar_target.table", entries(t.LocalVariables, func(er *errReader) LocalVariableTarget {
+				var v LocalVariableTarget
+				item(er, "start_pc", integer(&v.StartPC))
+				item(er, "length", integer(&v.Length))
+				item(er, "index", integer(&v.Index))
+				return v
+			}))
+		}
+	case TargetExceptionParameter:
+		item(er, "catch_target.exception_table_index", integer(&t.ExceptionTableIndex))
+	case TargetInstanceof, TargetNew, TargetConstructorReference, TargetMethodReference:
+		item(er, "offset_target.offset", integer(&t.Offset))
+	case TargetCast, TargetConstructorInvocationTypeArgument, TargetMethodInvocationTypeArgument,
+		TargetConstructorReferenceTypeArgument, TargetMethodReferenceTypeArgument:
+		item(er, "type_argument_target.offset", integer(&t.Offset))
+		item(er, "type_argument_target.type_argument_index", integer(&t.TypeArgumentIndex))
+	}
+
+	var pathLength uint8
+	if item(er, "type_path.path_length", integer(&pathLength)) {
+		a.targetPath = makeTable[TypePathEntry](er, cf, int(pathLength))
+		item(er, "type_path.path", entries(a.targetPath, func(er *errReader) TypePathEntry {
+			var p TypePathEntry
+			item(er, "type_path_kind", integer(&p.Kind, max(TypePathTypeArgument)))
+			item(er, "type_argument_index", integer(&p.TypeArgumentIndex))
+			return p
+		}))
+	}
+
+	a.annotation = parseAnnotation(er, cf)
+	a.span = spanFrom(er, offset)
+	return a
+}
+
+// TargetType is the kind of target on which a type annotation appears.
+type TargetType uint8
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.20-400
+const (
+	TargetClassTypeParameter                TargetType = 0x00
+	TargetMethodTypeParameter               TargetType = 0x01
+	TargetClassExtends                      TargetType = 0x10
+	TargetClassTypeParameterBound           TargetType = 0x11
+	TargetMethodTypeParameterBound          TargetType = 0x12
+	TargetField                             TargetType = 0x13
+	TargetMethodReturn                      TargetType = 0x14
+	TargetMethodReceiver                    TargetType = 0x15
+	TargetMethodFormalParameter             TargetType = 0x16
+	TargetThrows                            TargetType = 0x17
+	TargetLocalVariable                     TargetType = 0x40
+	TargetResourceVariable                  TargetType = 0x41
+	TargetExceptionParameter                TargetType = 0x42
+	TargetInstanceof                        TargetType = 0x43
+	TargetNew                               TargetType = 0x44
+	TargetConstructorReference              TargetType = 0x45
+	TargetMethodReference                   TargetType = 0x46
+	TargetCast                              TargetType = 0x47
+	TargetConstructorInvocationTypeArgument TargetType = 0x48
+	TargetMethodInvocationTypeArgument      TargetType = 0x49
+	TargetConstructorReferenceTypeArgument  TargetType = 0x4A
+	TargetMethodReferenceTypeArgument       TargetType = 0x4B
+)
+
+// target types allowed in the attributes of each structure
+var (
+	classTypeAnnotationTargets = []TargetType{
+		TargetClassTypeParameter, TargetClassExtends, TargetClassTypeParameterBound,
+	}
+	fieldTypeAnnotationTargets = []TargetType{
+		TargetField,
+	}
+	methodTypeAnnotationTargets = []TargetType{
+		TargetMethodTypeParameter, TargetMethodTypeParameterBound, TargetMethodReturn,
+		TargetMethodReceiver, TargetMethodFormalParameter, TargetThrows,
+	}
+	codeTypeAnnotationTargets = []TargetType{
+		TargetLocalVariable, TargetResourceVariable, TargetExceptionParameter,
+		TargetInstanceof, TargetNew, TargetConstructorReference, TargetMethodReference,
+		TargetCast, TargetConstructorInvocationTypeArgument, TargetMethodInvocationTypeArgument,
+		TargetConstructorReferenceTypeArgument, TargetMethodReferenceTypeArgument,
+	}
+)
+
+// TypeAnnotationTarget is the target_info of a type annotation.
+// Only the items of the target_info variant for the TargetType are meaningful.
+type TypeAnnotationTarget struct {
+	// TypeParameterIndex is the index of the type parameter declaration.
+	TypeParameterIndex uint8
+	// SupertypeIndex is the index into the interfaces array, or 65535 for the superclass.
+	SupertypeIndex uint16
+	// BoundIndex is the index of the bound of the type parameter declaration.
+	BoundIndex uint8
+	// FormalParameterIndex is the index of the formal parameter of the method.
+	FormalParameterIndex uint8
+	// ThrowsTypeIndex is the index into the exception_index_table of the Exceptions attribute.
+	ThrowsTypeIndex uint16
+	// LocalVariables are the ranges in which the local variable has a value.
+	LocalVariables []LocalVariableTarget
+	// ExceptionTableIndex is the index into the exception_table of the Code attribute.
+	ExceptionTableIndex uint16
+	// Offset is the offset of the bytecode instruction corresponding to the expression.
+	Offset uint16
+	// TypeArgumentIndex is the index of the type argument in the expression.
+	TypeArgumentIndex uint8
+}
+
+// LocalVariableTarget is a range of bytecode offsets in which a local variable has a value.
+type LocalVariableTarget struct {
+	StartPC, Length uint16
+	Index           uint16
+}
+
+// TypePathKind is the kind of a step of the type_path.
+type TypePathKind uint8
+
+const (
+	// TypePathArray is deeper in an array type.
+	TypePathArray TypePathKind = 0
+	// TypePathNested is deeper in a nested type.
+	TypePathNested TypePathKind = 1
+	// TypePathWildcard is on the bound of a wildcard type argument of a parameterized type.
+	TypePathWildcard TypePathKind = 2
+	// TypePathTypeArgument is on a type argument of a parameterized type.
+	TypePathTypeArgument TypePathKind = 3
+)
+
+// TypePathEntry is a step of the type_path which locates the annotated part of a type.
+type TypePathEntry struct {
+	Kind TypePathKind
+	// TypeArgumentIndex is the index of the type argument for TypePathTypeArgument, otherwise 0.
+	TypeArgumentIndex uint8
+}
+
+// TypeAnnotation is an annotation which appears on a use of a type.
+type TypeAnnotation struct {
+	TargetType TargetType
+	Target     TypeAnnotationTarget
+	// TypePath locates the annotated part of the target type, and is empty if the annotation is on the type itself.
+	TypePath []TypePathEntry
+	// Annotation is the annotation with its element values. Its Span covers only the annotation part of the type_annotation.
+	Annotation
+	// Span is the bytes of the type_annotation structure.
+	Span Span
+}
+
+func typeAnnotations(cf *ClassFile, attrs []attributeInfo) []TypeAnnotation {
+	var as []TypeAnnotation
+	resolve := func(a typeAnnotation, visible bool) TypeAnnotation {
+		ta := TypeAnnotation{
+			TargetType: a.targetType,
+			Target:     a.targetInfo,
+			Annotation: resolveAnnotation(cf, a.annotation, visible),
+			Span:       a.span,
+		}
+		// the slices are copied so that the callers cannot modify the parsed structure
+		if 0 < len(a.targetPath) {
+			ta.TypePath = append([]TypePathEntry(nil), a.targetPath...)
+		}
+		if 0 < len(a.targetInfo.LocalVariables) {
+			ta.Target.LocalVariables = append([]LocalVariableTarget(nil), a.targetInfo.LocalVariables...)
+		}
+		return ta
+	}
+	for _, a := range attrs {
+		switch attr := resolveAttributeNamed(a, "RuntimeVisibleTypeAnnotations", "RuntimeInvisibleTypeAnnotations").(type) {
+		case *attributeRuntimeVisibleTypeAnnotations:
+			for _, ta := range attr.annotations {
+				as = append(as, resolve(ta, true))
+			}
+		case *attributeRuntimeInvisibleTypeAnnotations:
+			for _, ta := range attr.annotations {
+				as = append(as, resolve(ta, false))
+			}
+		}
+	}
+	return as
+}
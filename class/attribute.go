@@ -4,75 +4,269 @@ import (
 	"fmt"
 )
 
+// Attribute is an attribute of a ClassFile, field_info, method_info or Code_attribute structure.
+type Attribute interface {
+	// Name returns the attribute name, such as "Code" or "SourceFile".
+	Name() string
+	// Span returns the bytes of the whole attribute including attribute_name_index and attribute_length.
+	Span() Span
+}
+
 type attributeInfo interface {
+	Attribute
 	_attributeInfo()
 }
 
+// parseAttributeInfo parses an attribute_info structure, whose info is parsed by the body for the attribute name.
+// An attribute which is not predefined in the class file version is parsed as an unrecognized attribute in the context.
+func parseAttributeInfo(er *errReader, cf *ClassFile, context AttributeContext, body func(base *attributeInfoBase, er *errReader) attributeInfo) attributeInfo {
+	base, ok := parseAttributeInfoBase(er, cf)
+	if !ok {
+		return nil
+	}
+	if !cf.predefinedAttribute(base.name) {
+		body = func(base *attributeInfoBase, er *errReader) attributeInfo {
+			return base.unknown(er, cf, context)
+		}
+	} else if kind, ok := lazyAttributes[base.name]; ok && !cf.options.Lenient {
+		// in lenient mode, the attribute is decoded here so that its problems are reported as diagnostics
+		if attr, ok := parseLazyAttribute(er, &base, kind, body); ok {
+			return attr
+		}
+	}
+	if cf.options.Lenient {
+		return parseLenientAttribute(er, cf, &base, body)
+	}
+	return parseBoundedAttribute(er, &base, body)
+}
+
+// parseBoundedAttribute parses the info of the attribute with a reader bounded by its attribute_length,
+// which fails when the body reads beyond it or leaves some of it unread.
+func parseBoundedAttribute(er *errReader, base *attributeInfoBase, body func(base *attributeInfoBase, er *errReader) attributeInfo) attributeInfo {
+	if err := er.r.check(int64(base.attributeLength)); err != nil {
+		er.fail(readError(err))
+		return nil
+	}
+	ir := *er
+	ir.r = er.r.sub(int64(base.attributeLength), ErrAttributeOverrun)
+	attr := body(base, &ir)
+	item(&ir, "info", eof)
+	er.err = ir.err
+	return attr
+}
+
 func parseAttributeInfoBase(er *errReader, cf *ClassFile) (base attributeInfoBase, ok bool) {
+	offset := er.r.n
 	if item(er, "attribute_name_index", integer(&base.attributeNameIndex)) {
-		validate(er, constantPoolStructure[uint16, *constantUtf8](cf))
+		validate(er, base.attributeNameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf))
 	} else {
 		return base, false
 	}
+	if er.err != nil {
+		return base, false
+	}
+	base.name = cf.utf8(base.attributeNameIndex)
 	item(er, "attribute_length", integer(&base.attributeLength))
+	base.span = Span{Offset: offset, Length: 6 + int64(base.attributeLength)}
 	return base, true
 }
 
+func parseClassAttributeInfo(er *errReader, cf *ClassFile) attributeInfo {
+	return parseAttributeInfo(er, cf, ContextClass, func(base *attributeInfoBase, er *errReader) attributeInfo {
+		switch base.name {
+		case "SourceFile":
+			return base.sourceFile(er, cf)
+		case "InnerClasses":
+			return base.innerClasses(er, cf)
+		case "EnclosingMethod":
+			return base.enclosingMethod(er, cf)
+		case "SourceDebugExtension":
+			return base.sourceDebugExtension(er, cf)
+		case "BootstrapMethods":
+			return base.bootstrapMethods(er, cf)
+		case "NestHost":
+			return base.nestHost(er, cf)
+		case "NestMembers":
+			return base.nestMembers(er, cf)
+		case "Record":
+			return base.record(er, cf)
+		case "PermittedSubclasses":
+			return base.permittedSubclasses(er, cf)
+		case "Module":
+			return base.module(er, cf)
+		case "ModulePackages":
+			return base.modulePackages(er, cf)
+		case "ModuleMainClass":
+			return base.moduleMainClass(er, cf)
+		case "Synthetic":
+			return base.synthetic(er, cf)
+		case "Deprecated":
+			return base.deprecated(er, cf)
+		case "Signature":
+			return base.signature(er, cf, ContextClass)
+		case "RuntimeVisibleAnnotations":
+			return base.runtimeVisibleAnnotations(er, cf)
+		case "RuntimeInvisibleAnnotations":
+			return base.runtimeInvisibleAnnotations(er, cf)
+		case "RuntimeVisibleTypeAnnotations":
+			return base.runtimeVisibleTypeAnnotations(er, cf, classTypeAnnotationTargets)
+		case "RuntimeInvisibleTypeAnnotations":
+			return base.runtimeInvisibleTypeAnnotations(er, cf, classTypeAnnotationTargets)
+		}
+
+		return base.unknown(er, cf, ContextClass)
+	})
+}
+
+func parseRecordComponentAttributeInfo(er *errReader, cf *ClassFile) attributeInfo {
+	return parseAttributeInfo(er, cf, ContextRecordComponent, func(base *attributeInfoBase, er *errReader) attributeInfo {
+		switch base.name {
+		case "Signature":
+			return base.signature(er, cf, ContextField)
+		case "RuntimeVisibleAnnotations":
+			return base.runtimeVisibleAnnotations(er, cf)
+		case "RuntimeInvisibleAnnotations":
+			return base.runtimeInvisibleAnnotations(er, cf)
+		case "RuntimeVisibleTypeAnnotations":
+			return base.runtimeVisibleTypeAnnotations(er, cf, fieldTypeAnnotationTargets)
+		case "RuntimeInvisibleTypeAnnotations":
+			return base.runtimeInvisibleTypeAnnotations(er, cf, fieldTypeAnnotationTargets)
+		}
+
+		return base.unknown(er, cf, ContextRecordComponent)
+	})
+}
+
 func parseFieldAttributeInfo(er *errReader, cf *ClassFile) attributeInfo {
-	base, ok := parseAttributeInfoBase(er, cf)
-	if !ok {
-		return nil
-	}
+	return parseAttributeInfo(er, cf, ContextField, func(base *attributeInfoBase, er *errReader) attributeInfo {
+		switch base.name {
+		case "ConstantValue":
+			return base.constantValue(er, cf)
+		case "Synthetic":
+			return base.synthetic(er, cf)
+		case "Deprecated":
+			return base.deprecated(er, cf)
+		case "Signature":
+			return base.signature(er, cf, ContextField)
+		case "RuntimeVisibleAnnotations":
+			return base.runtimeVisibleAnnotations(er, cf)
+		case "RuntimeInvisibleAnnotations":
+			return base.runtimeInvisibleAnnotations(er, cf)
+		case "RuntimeVisibleTypeAnnotations":
+			return base.runtimeVisibleTypeAnnotations(er, cf, fieldTypeAnnotationTargets)
+		case "RuntimeInvisibleTypeAnnotations":
+			return base.runtimeInvisibleTypeAnnotations(er, cf, fieldTypeAnnotationTargets)
+		}
 
-	utf8 := getCpinfo[*constantUtf8](cf, base.attributeNameIndex)
-	switch utf8.String() {
-	case "ConstantValue":
-		return base.constantValue(er, cf)
-	case "Synthetic":
-		return base.synthetic(er, cf)
-	case "Deprecated":
-		return base.deprecated(er, cf)
-	case "Signature":
-		return base.signature(er, cf)
-	case "RuntimeVisibleAnnotations":
-		return base.runtimeVisibleAnnotations(er, cf)
-	case "RuntimeInvisibleAnnotations":
-		return base.runtimeInvisibleTypeAnnotations(er, cf)
-	case "RuntimeVisibleTypeAnnotations":
-		return base.runtimeVisibleTypeAnnotations(er, cf)
-	case "RuntimeInvisibleTypeAnnotations":
-		return base.runtimeInvisibleTypeAnnotations(er, cf)
-	}
+		return base.unknown(er, cf, ContextField)
+	})
+}
+
+func parseMethodAttributeInfo(er *errReader, cf *ClassFile) attributeInfo {
+	return parseAttributeInfo(er, cf, ContextMethod, func(base *attributeInfoBase, er *errReader) attributeInfo {
+		switch base.name {
+		case "Code":
+			return base.code(er, cf)
+		case "Exceptions":
+			return base.exceptions(er, cf)
+		case "MethodParameters":
+			return base.methodParameters(er, cf)
+		case "Synthetic":
+			return base.synthetic(er, cf)
+		case "Deprecated":
+			return base.deprecated(er, cf)
+		case "Signature":
+			return base.signature(er, cf, ContextMethod)
+		case "RuntimeVisibleAnnotations":
+			return base.runtimeVisibleAnnotations(er, cf)
+		case "RuntimeInvisibleAnnotations":
+			return base.runtimeInvisibleAnnotations(er, cf)
+		case "RuntimeVisibleTypeAnnotations":
+			return base.runtimeVisibleTypeAnnotations(er, cf, methodTypeAnnotationTargets)
+		case "RuntimeInvisibleTypeAnnotations":
+			return base.runtimeInvisibleTypeAnnotations(er, cf, methodTypeAnnotationTargets)
+		case "RuntimeVisibleParameterAnnotations":
+			return base.runtimeVisibleParameterAnnotations(er, cf)
+		case "RuntimeInvisibleParameterAnnotations":
+			return base.runtimeInvisibleParameterAnnotations(er, cf)
+		case "AnnotationDefault":
+			return base.annotationDefault(er, cf)
+		}
 
-	er.err = fmt.Errorf("unsupported attribute name at index(%d)", base.attributeNameIndex)
-	return nil
+		return base.unknown(er, cf, ContextMethod)
+	})
 }
 
 func parseCodeAttributeInfo(er *errReader, cf *ClassFile) attributeInfo {
-	base, ok := parseAttributeInfoBase(er, cf)
-	if !ok {
-		return nil
-	}
-
-	utf8 := getCpinfo[*constantUtf8](cf, base.attributeNameIndex)
-	switch utf8.String() {
-	case "LineNumberTable":
-	case "LocalVariableTable":
-	case "LocalVariableTypeTable":
-	case "StackMapTable":
-	}
+	return parseAttributeInfo(er, cf, ContextCode, func(base *attributeInfoBase, er *errReader) attributeInfo {
+		switch base.name {
+		case "LineNumberTable":
+			return base.lineNumberTable(er, cf)
+		case "LocalVariableTable":
+			return base.localVariableTable(er, cf)
+		case "LocalVariableTypeTable":
+			return base.localVariableTypeTable(er, cf)
+		case "StackMapTable":
+			return base.stackMapTable(er, cf)
+		case "RuntimeVisibleTypeAnnotations":
+			return base.runtimeVisibleTypeAnnotations(er, cf, codeTypeAnnotationTargets)
+		case "RuntimeInvisibleTypeAnnotations":
+			return base.runtimeInvisibleTypeAnnotations(er, cf, codeTypeAnnotationTargets)
+		}
 
-	er.err = fmt.Errorf("unsupported attribute name at index(%d)", base.attributeNameIndex)
-	return nil
+		return base.unknown(er, cf, ContextCode)
+	})
 }
 
 type attributeInfoBase struct {
 	attributeNameIndex uint16
 	attributeLength    uint32
+
+	name string
+	span Span
 }
 
 func (attributeInfoBase) _attributeInfo() {}
 
+func (a attributeInfoBase) Name() string { return a.name }
+
+func (a attributeInfoBase) Span() Span { return a.span }
+
+func findAttribute[T attributeInfo](attrs []attributeInfo) (attr T, ok bool) {
+	for _, a := range attrs {
+		if l, lazy := a.(*lazyAttribute); lazy {
+			// decode only the attribute of the type
+			if _, ok = l.kind.(T); !ok {
+				continue
+			}
+			a, _ = l.resolve()
+		}
+		if attr, ok = a.(T); ok {
+			return attr, true
+		}
+	}
+	return attr, false
+}
+
+func constantPoolIndexes[V Constant](er *errReader, cf *ClassFile, name string, n uint16) []uint16 {
+	indexes := makeTable[uint16](er, cf, int(n))
+	item(er, name, entries(indexes, func(er *errReader) uint16 {
+		var idx uint16
+		item(er, name, integer(&idx, constantPoolStructure[uint16, V](cf)))
+		return idx
+	}))
+	return indexes
+}
+
+func optionalUtf8Index(er *errReader, cf *ClassFile, name string, idx *uint16) {
+	if item(er, name, integer(idx)) {
+		// zero indicates that no information is present
+		if *idx != 0 {
+			validate(er, *idx, constantPoolStructure[uint16, *ConstantUtf8](cf))
+		}
+	}
+}
+
 type attributeConstantValue struct {
 	attributeInfoBase
 	constantValueIndex uint16
@@ -82,27 +276,27 @@ func (base *attributeInfoBase) constantValue(er *errReader, cf *ClassFile) *attr
 	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.2
 	attr := attributeConstantValue{attributeInfoBase: *base}
 	if base.attributeLength != 2 {
-		er.err = fmt.Errorf("invalid attribute length(%d) for ConstantValue", base.attributeLength)
+		er.fail(fmt.Errorf("invalid attribute length(%d) for ConstantValue", base.attributeLength))
 		return nil
 	}
 
 	if item(er, "ConstantValue_attribute's constantvalue_index", integer(&attr.constantValueIndex, existConstantPool[uint16](cf))) {
-		e := must(cf.lookupConstantPool(attr.constantValueIndex))
+		e, _ := cf.lookupConstantPool(attr.constantValueIndex)
 
-		//TODO validate to match field types
+		// the type is validated against the field descriptor by parseField
 		switch e.(type) {
-		case *constantInteger:
+		case *ConstantInteger:
 			// int, short, char, byte, boolean
-		case *constantFloat:
+		case *ConstantFloat:
 			// float
-		case *constantLong:
+		case *ConstantLong:
 			// long
-		case *constantDouble:
+		case *ConstantDouble:
 			// double
-		case *constantString:
+		case *ConstantString:
 			// String
 		default:
-			er.err = fmt.Errorf("invalid constant pool entry structure at constantValueIndex(%d)", attr.constantValueIndex)
+			er.fail(fmt.Errorf("invalid constant pool entry structure at constantValueIndex(%d)", attr.constantValueIndex))
 			return nil
 		}
 	}
@@ -110,6 +304,52 @@ func (base *attributeInfoBase) constantValue(er *errReader, cf *ClassFile) *attr
 	return &attr
 }
 
+type attributeExceptions struct {
+	attributeInfoBase
+	numberOfExceptions  uint16
+	exceptionIndexTable []uint16
+}
+
+func (base *attributeInfoBase) exceptions(er *errReader, cf *ClassFile) *attributeExceptions {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.5
+	attr := attributeExceptions{attributeInfoBase: *base}
+	if item(er, "number_of_exceptions", integer(&attr.numberOfExceptions)) {
+		attr.exceptionIndexTable = constantPoolIndexes[*ConstantClass](er, cf, "exception_index_table", attr.numberOfExceptions)
+	}
+	return &attr
+}
+
+type methodParameter struct {
+	nameIndex   uint16
+	accessFlags ParameterAccessFlags
+}
+
+type attributeMethodParameters struct {
+	attributeInfoBase
+	parametersCount uint8
+	parameters      []methodParameter
+}
+
+func (base *attributeInfoBase) methodParameters(er *errReader, cf *ClassFile) *attributeMethodParameters {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.24
+	attr := attributeMethodParameters{attributeInfoBase: *base}
+	if item(er, "parameters_count", integer(&attr.parametersCount)) {
+		attr.parameters = makeTable[methodParameter](er, cf, int(attr.parametersCount))
+		item(er, "parameters", entries(attr.parameters, func(er *errReader) methodParameter {
+			var p methodParameter
+			if item(er, "name_index", integer(&p.nameIndex)) {
+				// zero indicates a formal parameter with no name
+				if p.nameIndex != 0 {
+					validate(er, p.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf))
+				}
+			}
+			item(er, "access_flags", integer(&p.accessFlags))
+			return p
+		}))
+	}
+	return &attr
+}
+
 type attributeSynthetic struct {
 	attributeInfoBase
 }
@@ -118,7 +358,7 @@ func (base *attributeInfoBase) synthetic(er *errReader, cf *ClassFile) *attribut
 	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.8
 	attr := attributeSynthetic{attributeInfoBase: *base}
 	if base.attributeLength != 0 {
-		er.err = fmt.Errorf("invalid attribute length(%d) for Synthetic_attribute", base.attributeLength)
+		er.fail(fmt.Errorf("invalid attribute length(%d) for Synthetic_attribute", base.attributeLength))
 		return nil
 	}
 	return &attr
@@ -132,7 +372,7 @@ func (base *attributeInfoBase) deprecated(er *errReader, cf *ClassFile) *attribu
 	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.15
 	attr := attributeDeprecated{attributeInfoBase: *base}
 	if base.attributeLength != 0 {
-		er.err = fmt.Errorf("invalid attribute length(%d) for Deprecated_attribute", base.attributeLength)
+		er.fail(fmt.Errorf("invalid attribute length(%d) for Deprecated_attribute", base.attributeLength))
 		return nil
 	}
 	return &attr
@@ -143,23 +383,27 @@ type attributeSignature struct {
 	signatureIndex uint16
 }
 
-func (base *attributeInfoBase) signature(er *errReader, cf *ClassFile) *attributeSignature {
+func (base *attributeInfoBase) signature(er *errReader, cf *ClassFile, context AttributeContext) *attributeSignature {
 	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.9
 	attr := attributeSignature{attributeInfoBase: *base}
 	if base.attributeLength != 2 {
-		er.err = fmt.Errorf("invalid attribute length(%d) for Signature_attribute", base.attributeLength)
+		er.fail(fmt.Errorf("invalid attribute length(%d) for Signature_attribute", base.attributeLength))
 		return nil
 	}
 
-	if item(er, "signature_index", integer(&attr.signatureIndex, constantPoolStructure[uint16, *constantUtf8](cf))) {
-		utf8 := getCpinfo[*constantUtf8](cf, attr.signatureIndex)
-		switch utf8.String() {
-		//TODO
-		// a class signature if this Signature attribute is an attribute of a ClassFile structure
-		// a method signature if this Signature attribute is an attribute of a method_info structure
-		// or a field signature otherwise.
-		}
+	// a class signature if this Signature attribute is an attribute of a ClassFile structure,
+	// a method signature if this Signature attribute is an attribute of a method_info structure,
+	// or a field signature otherwise.
+	var v validator[uint16]
+	switch context {
+	case ContextClass:
+		v = classSignature(cf)
+	case ContextMethod:
+		v = methodSignature(cf)
+	default:
+		v = fieldSignature(cf)
 	}
+	item(er, "signature_index", integer(&attr.signatureIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), v))
 	return &attr
 }
 
@@ -173,6 +417,7 @@ func (base *attributeInfoBase) runtimeVisibleAnnotations(er *errReader, cf *Clas
 	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.16
 	attr := attributeRuntimeVisibleAnnotations{attributeInfoBase: *base}
 	item(er, "num_annotations", integer(&attr.numAnnotations))
+	attr.annotations = makeTable[annotation](er, cf, int(attr.numAnnotations))
 	item(er, "annotations", entries(attr.annotations, func(er *errReader) annotation {
 		return parseAnnotation(er, cf)
 	}))
@@ -189,7 +434,7 @@ func (base *attributeInfoBase) runtimeInvisibleAnnotations(er *errReader, cf *Cl
 	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.17
 	attr := attributeRuntimeInvisibleAnnotations{attributeInfoBase: *base}
 	item(er, "num_annotations", integer(&attr.numAnnotations))
-	attr.annotations = make([]annotation, attr.numAnnotations)
+	attr.annotations = makeTable[annotation](er, cf, int(attr.numAnnotations))
 	item(er, "annotations", entries(attr.annotations, func(er *errReader) annotation {
 		return parseAnnotation(er, cf)
 	}))
@@ -201,46 +446,300 @@ type parameterAnnotation struct {
 	annotations    []annotation
 }
 
-type attributeRuntimeVisibleTypeAnnotations struct {
+func parseParameterAnnotations(er *errReader, cf *ClassFile, numParameters uint8) []parameterAnnotation {
+	parameterAnnotations := makeTable[parameterAnnotation](er, cf, int(numParameters))
+	item(er, "parameter_annotations", entries(parameterAnnotations, func(er *errReader) parameterAnnotation {
+		var p parameterAnnotation
+		if item(er, "num_annotations", integer(&p.numAnnotations)) {
+			p.annotations = makeTable[annotation](er, cf, int(p.numAnnotations))
+			item(er, "annotations", entries(p.annotations, func(er *errReader) annotation {
+				return parseAnnotation(er, cf)
+			}))
+		}
+		return p
+	}))
+	return parameterAnnotations
+}
+
+type attributeRuntimeVisibleParameterAnnotations struct {
+	attributeInfoBase
+	numParameters        uint8
+	parameterAnnotations []parameterAnnotation
+}
+
+func (base *attributeInfoBase) runtimeVisibleParameterAnnotations(er *errReader, cf *ClassFile) *attributeRuntimeVisibleParameterAnnotations {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.18
+	attr := attributeRuntimeVisibleParameterAnnotations{attributeInfoBase: *base}
+	if item(er, "num_parameters", integer(&attr.numParameters)) {
+		attr.parameterAnnotations = parseParameterAnnotations(er, cf, attr.numParameters)
+	}
+	return &attr
+}
+
+type attributeRuntimeInvisibleParameterAnnotations struct {
 	attributeInfoBase
 	numParameters        uint8
 	parameterAnnotations []parameterAnnotation
 }
 
-func (base *attributeInfoBase) runtimeVisibleTypeAnnotations(er *errReader, cf *ClassFile) *attributeRuntimeVisibleTypeAnnotations {
+func (base *attributeInfoBase) runtimeInvisibleParameterAnnotations(er *errReader, cf *ClassFile) *attributeRuntimeInvisibleParameterAnnotations {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.19
+	attr := attributeRuntimeInvisibleParameterAnnotations{attributeInfoBase: *base}
+	if item(er, "num_parameters", integer(&attr.numParameters)) {
+		attr.parameterAnnotations = parseParameterAnnotations(er, cf, attr.numParameters)
+	}
+	return &attr
+}
+
+type attributeRuntimeVisibleTypeAnnotations struct {
+	attributeInfoBase
+	numAnnotations uint16
+	annotations    []typeAnnotation
+}
+
+func (base *attributeInfoBase) runtimeVisibleTypeAnnotations(er *errReader, cf *ClassFile, targets []TargetType) *attributeRuntimeVisibleTypeAnnotations {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.20
 	attr := attributeRuntimeVisibleTypeAnnotations{attributeInfoBase: *base}
-	item(er, "num_parameters", integer(&attr.numParameters))
-	attr.parameterAnnotations = make([]parameterAnnotation, attr.numParameters)
-	item(er, "annotations", entries(attr.parameterAnnotations, func(er *errReader) parameterAnnotation {
-		var p parameterAnnotation
-		item(er, "num_annotations", integer(&p.numAnnotations))
-		p.annotations = make([]annotation, p.numAnnotations)
-		item(er, "annotations", entries(p.annotations, func(er *errReader) annotation {
-			return parseAnnotation(er, cf)
+	if item(er, "num_annotations", integer(&attr.numAnnotations)) {
+		attr.annotations = makeTable[typeAnnotation](er, cf, int(attr.numAnnotations))
+		item(er, "annotations", entries(attr.annotations, func(er *errReader) typeAnnotation {
+			return parseTypeAnnotation(er, cf, targets)
 		}))
-		return p
-	}))
+	}
 	return &attr
 }
 
 type attributeRuntimeInvisibleTypeAnnotations struct {
 	attributeInfoBase
-	numParameters        uint8
-	parameterAnnotations []parameterAnnotation
+	numAnnotations uint16
+	annotations    []typeAnnotation
 }
 
-func (base *attributeInfoBase) runtimeInvisibleTypeAnnotations(er *errReader, cf *ClassFile) *attributeRuntimeInvisibleTypeAnnotations {
+func (base *attributeInfoBase) runtimeInvisibleTypeAnnotations(er *errReader, cf *ClassFile, targets []TargetType) *attributeRuntimeInvisibleTypeAnnotations {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.21
 	attr := attributeRuntimeInvisibleTypeAnnotations{attributeInfoBase: *base}
-	item(er, "num_parameters", integer(&attr.numParameters))
-	attr.parameterAnnotations = make([]parameterAnnotation, attr.numParameters)
-	item(er, "annotations", entries(attr.parameterAnnotations, func(er *errReader) parameterAnnotation {
-		var p parameterAnnotation
-		item(er, "num_annotations", integer(&p.numAnnotations))
-		p.annotations = make([]annotation, p.numAnnotations)
-		item(er, "annotations", entries(p.annotations, func(er *errReader) annotation {
-			return parseAnnotation(er, cf)
+	if item(er, "num_annotations", integer(&attr.numAnnotations)) {
+		attr.annotations = makeTable[typeAnnotation](er, cf, int(attr.numAnnotations))
+		item(er, "annotations", entries(attr.annotations, func(er *errReader) typeAnnotation {
+			return parseTypeAnnotation(er, cf, targets)
 		}))
-		return p
-	}))
+	}
+	return &attr
+}
+
+type attributeAnnotationDefault struct {
+	attributeInfoBase
+	defaultValue elementValue
+}
+
+func (base *attributeInfoBase) annotationDefault(er *errReader, cf *ClassFile) *attributeAnnotationDefault {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.22
+	attr := attributeAnnotationDefault{attributeInfoBase: *base}
+	attr.defaultValue = parseElementValue(er, cf)
+	return &attr
+}
+
+type attributeSourceFile struct {
+	attributeInfoBase
+	sourceFileIndex uint16
+}
+
+func (base *attributeInfoBase) sourceFile(er *errReader, cf *ClassFile) *attributeSourceFile {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.10
+	attr := attributeSourceFile{attributeInfoBase: *base}
+	if base.attributeLength != 2 {
+		er.fail(fmt.Errorf("invalid attribute length(%d) for SourceFile_attribute", base.attributeLength))
+		return nil
+	}
+	item(er, "sourcefile_index", integer(&attr.sourceFileIndex, constantPoolStructure[uint16, *ConstantUtf8](cf)))
+	return &attr
+}
+
+type innerClass struct {
+	innerClassInfoIndex   uint16
+	outerClassInfoIndex   uint16
+	innerNameIndex        uint16
+	innerClassAccessFlags InnerClassAccessFlags
+}
+
+type attributeInnerClasses struct {
+	attributeInfoBase
+	numberOfClasses uint16
+	classes         []innerClass
+}
+
+func (base *attributeInfoBase) innerClasses(er *errReader, cf *ClassFile) *attributeInnerClasses {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.6
+	attr := attributeInnerClasses{attributeInfoBase: *base}
+	if item(er, "number_of_classes", integer(&attr.numberOfClasses)) {
+		attr.classes = makeTable[innerClass](er, cf, int(attr.numberOfClasses))
+		item(er, "classes", entries(attr.classes, func(er *errReader) innerClass {
+			var c innerClass
+			item(er, "inner_class_info_index", integer(&c.innerClassInfoIndex, constantPoolStructure[uint16, *ConstantClass](cf)))
+			if item(er, "outer_class_info_index", integer(&c.outerClassInfoIndex)) {
+				// zero if C is not a member of a class or an interface
+				if c.outerClassInfoIndex != 0 {
+					validate(er, c.outerClassInfoIndex, constantPoolStructure[uint16, *ConstantClass](cf))
+				}
+			}
+			if item(er, "inner_name_index", integer(&c.innerNameIndex)) {
+				// zero if C is anonymous
+				if c.innerNameIndex != 0 {
+					validate(er, c.innerNameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf))
+				}
+			}
+			item(er, "inner_class_access_flags", integer(&c.innerClassAccessFlags))
+			return c
+		}))
+	}
+	return &attr
+}
+
+type attributeEnclosingMethod struct {
+	attributeInfoBase
+	classIndex  uint16
+	methodIndex uint16
+}
+
+func (base *attributeInfoBase) enclosingMethod(er *errReader, cf *ClassFile) *attributeEnclosingMethod {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.7
+	attr := attributeEnclosingMethod{attributeInfoBase: *base}
+	if base.attributeLength != 4 {
+		er.fail(fmt.Errorf("invalid attribute length(%d) for EnclosingMethod_attribute", base.attributeLength))
+		return nil
+	}
+	item(er, "class_index", integer(&attr.classIndex, constantPoolStructure[uint16, *ConstantClass](cf)))
+	if item(er, "method_index", integer(&attr.methodIndex)) {
+		// zero if the current class is not immediately enclosed by a method or constructor
+		if attr.methodIndex != 0 {
+			validate(er, attr.methodIndex, constantPoolStructure[uint16, *ConstantNameAndType](cf))
+		}
+	}
+	return &attr
+}
+
+type attributeSourceDebugExtension struct {
+	attributeInfoBase
+	debugExtension []byte
+}
+
+func (base *attributeInfoBase) sourceDebugExtension(er *errReader, cf *ClassFile) *attributeSourceDebugExtension {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.11
+	attr := attributeSourceDebugExtension{attributeInfoBase: *base}
+	item(er, "debug_extension", slice(&attr.debugExtension, int(base.attributeLength)))
+	return &attr
+}
+
+type bootstrapMethod struct {
+	bootstrapMethodRef    uint16
+	numBootstrapArguments uint16
+	bootstrapArguments    []uint16
+}
+
+type attributeBootstrapMethods struct {
+	attributeInfoBase
+	numBootstrapMethods uint16
+	bootstrapMethods    []bootstrapMethod
+}
+
+func (base *attributeInfoBase) bootstrapMethods(er *errReader, cf *ClassFile) *attributeBootstrapMethods {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.23
+	attr := attributeBootstrapMethods{attributeInfoBase: *base}
+	if item(er, "num_bootstrap_methods", integer(&attr.numBootstrapMethods)) {
+		attr.bootstrapMethods = makeTable[bootstrapMethod](er, cf, int(attr.numBootstrapMethods))
+		item(er, "bootstrap_methods", entries(attr.bootstrapMethods, func(er *errReader) bootstrapMethod {
+			var m bootstrapMethod
+			item(er, "bootstrap_method_ref", integer(&m.bootstrapMethodRef, constantPoolStructure[uint16, *ConstantMethodHandle](cf)))
+			if item(er, "num_bootstrap_arguments", integer(&m.numBootstrapArguments)) {
+				m.bootstrapArguments = makeTable[uint16](er, cf, int(m.numBootstrapArguments))
+				item(er, "bootstrap_arguments", entries(m.bootstrapArguments, func(er *errReader) uint16 {
+					var idx uint16
+					item(er, "bootstrap_arguments", integer(&idx, existConstantPool[uint16](cf)))
+					return idx
+				}))
+			}
+			return m
+		}))
+	}
+	return &attr
+}
+
+type attributeNestHost struct {
+	attributeInfoBase
+	hostClassIndex uint16
+}
+
+func (base *attributeInfoBase) nestHost(er *errReader, cf *ClassFile) *attributeNestHost {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.28
+	attr := attributeNestHost{attributeInfoBase: *base}
+	if base.attributeLength != 2 {
+		er.fail(fmt.Errorf("invalid attribute length(%d) for NestHost_attribute", base.attributeLength))
+		return nil
+	}
+	item(er, "host_class_index", integer(&attr.hostClassIndex, constantPoolStructure[uint16, *ConstantClass](cf)))
+	return &attr
+}
+
+type attributeNestMembers struct {
+	attributeInfoBase
+	numberOfClasses uint16
+	classes         []uint16
+}
+
+func (base *attributeInfoBase) nestMembers(er *errReader, cf *ClassFile) *attributeNestMembers {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.29
+	attr := attributeNestMembers{attributeInfoBase: *base}
+	if item(er, "number_of_classes", integer(&attr.numberOfClasses)) {
+		attr.classes = constantPoolIndexes[*ConstantClass](er, cf, "classes", attr.numberOfClasses)
+	}
+	return &attr
+}
+
+type recordComponentInfo struct {
+	nameIndex       uint16
+	descriptorIndex uint16
+	attributesCount uint16
+	attributes      []attributeInfo
+}
+
+type attributeRecord struct {
+	attributeInfoBase
+	componentsCount uint16
+	components      []recordComponentInfo
+}
+
+func (base *attributeInfoBase) record(er *errReader, cf *ClassFile) *attributeRecord {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.30
+	attr := attributeRecord{attributeInfoBase: *base}
+	if item(er, "components_count", integer(&attr.componentsCount)) {
+		attr.components = makeTable[recordComponentInfo](er, cf, int(attr.componentsCount))
+		item(er, "components", entries(attr.components, func(er *errReader) recordComponentInfo {
+			var c recordComponentInfo
+			item(er, "name_index", integer(&c.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf)))
+			item(er, "descriptor_index", integer(&c.descriptorIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), fieldDescriptor(cf)))
+			if item(er, "attributes_count", integer(&c.attributesCount)) {
+				c.attributes = makeTable[attributeInfo](er, cf, int(c.attributesCount))
+				item(er, "attributes", entries(c.attributes, func(er *errReader) attributeInfo {
+					return parseRecordComponentAttributeInfo(er, cf)
+				}))
+			}
+			return c
+		}))
+	}
+	return &attr
+}
+
+type attributePermittedSubclasses struct {
+	attributeInfoBase
+	numberOfClasses uint16
+	classes         []uint16
+}
+
+func (base *attributeInfoBase) permittedSubclasses(er *errReader, cf *ClassFile) *attributePermittedSubclasses {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.31
+	attr := attributePermittedSubclasses{attributeInfoBase: *base}
+	if item(er, "number_of_classes", integer(&attr.numberOfClasses)) {
+		attr.classes = constantPoolIndexes[*ConstantClass](er, cf, "classes", attr.numberOfClasses)
+	}
 	return &attr
 }
@@ -0,0 +1,206 @@
+package class
+
+import (
+	"fmt"
+	"sync"
+)
+
+// AttributeContext is the structure in which an attribute appears.
+type AttributeContext uint8
+
+const (
+	ContextClass AttributeContext = iota
+	ContextField
+	ContextMethod
+	ContextCode
+	ContextRecordComponent
+)
+
+func (c AttributeContext) String() string {
+	switch c {
+	case ContextClass:
+		return "ClassFile"
+	case ContextField:
+		return "field_info"
+	case ContextMethod:
+		return "method_info"
+	case ContextCode:
+		return "Code_attribute"
+	case ContextRecordComponent:
+		return "record_component_info"
+	}
+	return fmt.Sprintf("AttributeContext(%d)", uint8(c))
+}
+
+// AttributeDecoder decodes the info of an attribute which the parser does not recognize.
+// The decoder must read the whole info through r, and the returned value is kept as the Value of a DecodedAttribute.
+type AttributeDecoder func(r *AttributeReader) any
+
+type attributeDecoderKey struct {
+	context AttributeContext
+	name    string
+}
+
+// attributeDecoderEntry is a registration of a decoder, compared by its address since functions are not comparable.
+type attributeDecoderEntry struct {
+	decoder AttributeDecoder
+}
+
+var (
+	attributeDecodersMu sync.RWMutex
+	attributeDecoders   = map[attributeDecoderKey]*attributeDecoderEntry{}
+)
+
+// RegisterAttribute registers a decoder for the attribute with the name in the context,
+// replacing the decoder registered before, and returns the function to unregister it.
+// The unregister function does nothing once another decoder replaces this one.
+// The attributes defined by JVMS are always decoded by the parser itself,
+// and the attributes with no registered decoder are kept as RawAttribute.
+func RegisterAttribute(context AttributeContext, name string, decoder AttributeDecoder) (unregister func()) {
+	if decoder == nil {
+		panic("class: RegisterAttribute decoder is nil")
+	}
+	key := attributeDecoderKey{context: context, name: name}
+	entry := &attributeDecoderEntry{decoder: decoder}
+
+	attributeDecodersMu.Lock()
+	defer attributeDecodersMu.Unlock()
+	attributeDecoders[key] = entry
+
+	return func() {
+		attributeDecodersMu.Lock()
+		defer attributeDecodersMu.Unlock()
+		if attributeDecoders[key] == entry {
+			delete(attributeDecoders, key)
+		}
+	}
+}
+
+func lookupAttributeDecoder(context AttributeContext, name string) (AttributeDecoder, bool) {
+	attributeDecodersMu.RLock()
+	defer attributeDecodersMu.RUnlock()
+
+	e, ok := attributeDecoders[attributeDecoderKey{context: context, name: name}]
+	if !ok {
+		return nil, false
+	}
+	return e.decoder, true
+}
+
+// RawAttribute is an attribute with no decoder, kept as its info bytes.
+type RawAttribute struct {
+	attributeInfoBase
+	info []byte
+}
+
+// Info returns the info bytes of the attribute, which may share the memory with the input of ParseBytes.
+func (a *RawAttribute) Info() []byte { return a.info }
+
+// DecodedAttribute is an attribute decoded by a registered AttributeDecoder.
+type DecodedAttribute struct {
+	attributeInfoBase
+	value any
+}
+
+// Value returns the value returned by the AttributeDecoder.
+func (a *DecodedAttribute) Value() any { return a.value }
+
+func (base *attributeInfoBase) unknown(er *errReader, cf *ClassFile, context AttributeContext) attributeInfo {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.1
+	// unrecognized attributes must be silently ignored
+	var info []byte
+	if !item(er, "info", slice(&info, int(base.attributeLength))) {
+		return nil
+	}
+
+	decoder, ok := lookupAttributeDecoder(context, base.name)
+	if !ok {
+		return &RawAttribute{attributeInfoBase: *base, info: info}
+	}
+
+	r := AttributeReader{
+		er:      newAttributeErrReader(info, er.offset, er.structPath()),
+		cf:      cf,
+		base:    base,
+		context: context,
+	}
+	value := decoder(&r)
+	item(r.er, "info", eof)
+	if r.er.err != nil {
+		er.err = r.er.err
+		return nil
+	}
+	return &DecodedAttribute{attributeInfoBase: *base, value: value}
+}
+
+// AttributeReader reads the info of an attribute for an AttributeDecoder.
+// Once an error occurs, the subsequent reads return zero values and Err reports the first error.
+type AttributeReader struct {
+	er      *errReader
+	cf      *ClassFile
+	base    *attributeInfoBase
+	context AttributeContext
+}
+
+// Name returns the name of the attribute.
+func (r *AttributeReader) Name() string { return r.base.name }
+
+// Length returns the attribute_length of the attribute.
+func (r *AttributeReader) Length() uint32 { return r.base.attributeLength }
+
+// Context returns the structure in which the attribute appears.
+func (r *AttributeReader) Context() AttributeContext { return r.context }
+
+// Err returns the first error while reading the attribute.
+func (r *AttributeReader) Err() error { return r.er.err }
+
+// Fail reports that the info of the attribute is invalid.
+func (r *AttributeReader) Fail(err error) {
+	r.er.fail(err)
+}
+
+// U1 reads an unsigned one-byte item.
+func (r *AttributeReader) U1(name string) (v uint8) {
+	item(r.er, name, integer(&v))
+	return v
+}
+
+// U2 reads an unsigned two-byte item.
+func (r *AttributeReader) U2(name string) (v uint16) {
+	item(r.er, name, integer(&v))
+	return v
+}
+
+// U4 reads an unsigned four-byte item.
+func (r *AttributeReader) U4(name string) (v uint32) {
+	item(r.er, name, integer(&v))
+	return v
+}
+
+// Bytes reads n bytes.
+func (r *AttributeReader) Bytes(name string, n int) []byte {
+	var b []byte
+	if !item(r.er, name, slice(&b, n)) {
+		return nil
+	}
+	return b
+}
+
+// Utf8 reads an index into the constant pool which must be a CONSTANT_Utf8_info structure, and returns its string.
+func (r *AttributeReader) Utf8(name string) string {
+	var idx uint16
+	if !item(r.er, name, integer(&idx, constantPoolStructure[uint16, *ConstantUtf8](r.cf))) {
+		return ""
+	}
+	return r.cf.utf8(idx)
+}
+
+// ClassName reads an index into the constant pool which must be a CONSTANT_Class_info structure,
+// and returns the name of the class.
+func (r *AttributeReader) ClassName(name string) string {
+	var idx uint16
+	if !item(r.er, name, integer(&idx, constantPoolStructure[uint16, *ConstantClass](r.cf))) {
+		return ""
+	}
+	return r.cf.className(idx)
+}
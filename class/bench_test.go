@@ -0,0 +1,70 @@
+package class_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/thara/godiva/class"
+)
+
+func BenchmarkParse(b *testing.B) {
+	for name, data := range fixtures(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	for name, data := range fixtures(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseBytes(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseReaderAt(b *testing.B) {
+	for name, data := range fixtures(b) {
+		b.Run(name, func(b *testing.B) {
+			r := bytes.NewReader(data)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseReaderAt(r, int64(len(data))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseBytesDecodeAll measures ParseBytes when every attribute is accessed after all.
+func BenchmarkParseBytesDecodeAll(b *testing.B) {
+	for name, data := range fixtures(b) {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cf, err := ParseBytes(data)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := cf.DecodeAll(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package class_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// classBuilder assembles class files for tests which cannot rely on javac.
+type classBuilder struct {
+	minor, major uint16
+
+	cp      bytes.Buffer
+	cpCount uint16
+	cpIndex map[string]uint16
+
+	accessFlags uint16
+	thisClass   uint16
+	superClass  uint16
+	interfaces  []uint16
+	fields      [][]byte
+	methods     [][]byte
+	attributes  [][]byte
+}
+
+func newClassBuilder(name, super string) *classBuilder {
+	b := &classBuilder{major: 62, cpCount: 1, cpIndex: map[string]uint16{}, accessFlags: 0x0021}
+	b.thisClass = b.class(name)
+	if super != "" {
+		b.superClass = b.class(super)
+	}
+	return b
+}
+
+func u1(v uint8) []byte { return []byte{v} }
+
+func u2(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+func u4(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func concat(bs ...[]byte) []byte { return bytes.Join(bs, nil) }
+
+// entry adds a constant pool entry unless the same entry has already been added.
+func (b *classBuilder) entry(slots uint16, bs ...[]byte) uint16 {
+	e := concat(bs...)
+	if i, ok := b.cpIndex[string(e)]; ok {
+		return i
+	}
+	i := b.cpCount
+	b.cp.Write(e)
+	b.cpCount += slots
+	b.cpIndex[string(e)] = i
+	return i
+}
+
+func (b *classBuilder) utf8(s string) uint16 {
+	return b.entry(1, u1(1), u2(uint16(len(s))), []byte(s))
+}
+
+func (b *classBuilder) class(name string) uint16 {
+	return b.entry(1, u1(7), u2(b.utf8(name)))
+}
+
+func (b *classBuilder) str(s string) uint16 {
+	return b.entry(1, u1(8), u2(b.utf8(s)))
+}
+
+func (b *classBuilder) module(name string) uint16 {
+	return b.entry(1, u1(19), u2(b.utf8(name)))
+}
+
+func (b *classBuilder) pkg(name string) uint16 {
+	return b.entry(1, u1(20), u2(b.utf8(name)))
+}
+
+func (b *classBuilder) integer(v int32) uint16 {
+	return b.entry(1, u1(3), u4(uint32(v)))
+}
+
+func (b *classBuilder) float(v float32) uint16 {
+	return b.entry(1, u1(4), u4(math.Float32bits(v)))
+}
+
+func (b *classBuilder) long(v int64) uint16 {
+	return b.entry(2, u1(5), u4(uint32(uint64(v)>>32)), u4(uint32(v)))
+}
+
+func (b *classBuilder) double(v float64) uint16 {
+	bits := math.Float64bits(v)
+	return b.entry(2, u1(6), u4(uint32(bits>>32)), u4(uint32(bits)))
+}
+
+func (b *classBuilder) nameAndType(name, descriptor string) uint16 {
+	return b.entry(1, u1(12), u2(b.utf8(name)), u2(b.utf8(descriptor)))
+}
+
+func (b *classBuilder) fieldref(owner, name, descriptor string) uint16 {
+	return b.entry(1, u1(9), u2(b.class(owner)), u2(b.nameAndType(name, descriptor)))
+}
+
+func (b *classBuilder) methodref(owner, name, descriptor string) uint16 {
+	return b.entry(1, u1(10), u2(b.class(owner)), u2(b.nameAndType(name, descriptor)))
+}
+
+func (b *classBuilder) methodHandle(kind uint8, ref uint16) uint16 {
+	return b.entry(1, u1(15), u1(kind), u2(ref))
+}
+
+func (b *classBuilder) attribute(name string, body ...[]byte) []byte {
+	info := concat(body...)
+	return concat(u2(b.utf8(name)), u4(uint32(len(info))), info)
+}
+
+// returnCode returns a Code attribute of a single return instruction with the exception handlers covering it,
+// each of which catches the class at the constant_pool index, or any exception for 0.
+func (b *classBuilder) returnCode(catchTypes ...uint16) []byte {
+	var handlers []byte
+	for _, c := range catchTypes {
+		handlers = append(handlers, concat(u2(0), u2(1), u2(0), u2(c))...)
+	}
+	return b.attribute("Code", u2(1), u2(1), u4(1), u1(0xb1), u2(uint16(len(catchTypes))), handlers, u2(0))
+}
+
+func (b *classBuilder) member(accessFlags uint16, name, descriptor string, attrs ...[]byte) []byte {
+	return concat(u2(accessFlags), u2(b.utf8(name)), u2(b.utf8(descriptor)), u2(uint16(len(attrs))), concat(attrs...))
+}
+
+func (b *classBuilder) field(accessFlags uint16, name, descriptor string, attrs ...[]byte) {
+	b.fields = append(b.fields, b.member(accessFlags, name, descriptor, attrs...))
+}
+
+func (b *classBuilder) method(accessFlags uint16, name, descriptor string, attrs ...[]byte) {
+	b.methods = append(b.methods, b.member(accessFlags, name, descriptor, attrs...))
+}
+
+func (b *classBuilder) build() []byte {
+	var interfaces []byte
+	for _, i := range b.interfaces {
+		interfaces = append(interfaces, u2(i)...)
+	}
+	return concat(
+		[]byte{0xCA, 0xFE, 0xBA, 0xBE},
+		u2(b.minor), u2(b.major),
+		u2(b.cpCount), b.cp.Bytes(),
+		u2(b.accessFlags), u2(b.thisClass), u2(b.superClass),
+		u2(uint16(len(b.interfaces))), interfaces,
+		u2(uint16(len(b.fields))), concat(b.fields...),
+		u2(uint16(len(b.methods))), concat(b.methods...),
+		u2(uint16(len(b.attributes))), concat(b.attributes...),
+	)
+}
@@ -1,13 +1,11 @@
 package class
 
 import (
-	"errors"
 	"fmt"
 	"io"
-)
 
-//TODO
-type methodInfo byte
+	"github.com/thara/godiva/signature"
+)
 
 // ClassFile
 // https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1
@@ -16,7 +14,7 @@ type ClassFile struct {
 	MinorVer, MajorVer uint16
 
 	constantPoolCount uint16
-	ConstantPool      []cpInfo
+	ConstantPool      []Constant
 
 	AccessFlags     AccessFlags
 	thisClass       uint16
@@ -29,54 +27,202 @@ type ClassFile struct {
 	methods         []methodInfo
 	attributesCount uint16
 	attributes      []attributeInfo
+
+	options     Options
+	diagnostics []Diagnostic
+}
+
+// Options controls how a class file is parsed.
+type Options struct {
+	// Lenient makes the parser resume after an invalid attribute instead of stopping at the first error.
+	// The attribute is skipped by its attribute_length and kept as RawAttribute,
+	// and the problems are reported as diagnostics.
+	// Illegal access flags of the class, its fields and its methods are also reported as diagnostics.
+	Lenient bool
+	// MaxMajorVersion rejects the class files whose major_version is above it, such as 62 for Java 18.
+	// Zero means no limit.
+	MaxMajorVersion uint16
+	// Limits bounds the resources which a class file can make the parser use.
+	Limits Limits
+}
+
+// Default limits used for the zero fields of Limits.
+const (
+	DefaultMaxBytes = 64 << 20
+	DefaultMaxCount = 65535
+	DefaultMaxDepth = 64
+)
+
+// Limits bounds the resources which a class file can make the parser use,
+// so that a hostile class file fails with ErrLimitExceeded instead of exhausting the memory or the stack.
+// A zero field means its default limit.
+type Limits struct {
+	// MaxBytes is the maximum size of a class file.
+	MaxBytes int64
+	// MaxCount is the maximum number of entries in a table, such as constant_pool, methods or annotations.
+	MaxCount int
+	// MaxDepth is the maximum nesting depth of element_value structures in an annotation.
+	MaxDepth int
+}
+
+func (l Limits) maxBytes() int64 {
+	if l.MaxBytes == 0 {
+		return DefaultMaxBytes
+	}
+	return l.MaxBytes
+}
+
+func (l Limits) maxCount() int {
+	if l.MaxCount == 0 {
+		return DefaultMaxCount
+	}
+	return l.MaxCount
+}
+
+func (l Limits) maxDepth() int {
+	if l.MaxDepth == 0 {
+		return DefaultMaxDepth
+	}
+	return l.MaxDepth
 }
 
+// Parse parses a class file, stopping at the first error.
 func Parse(r io.Reader) (*ClassFile, error) {
-	er := errReader{r: r}
+	cf, _, err := ParseWithOptions(r, Options{})
+	return cf, err
+}
+
+// ParseWithOptions parses a class file with the options.
+//
+// In lenient mode, the problems which the parser can resume after are returned as diagnostics
+// instead of an error. If the parser cannot resume, the error is also returned as a fatal diagnostic
+// together with the partial ClassFile, which holds only the entries parsed before the error.
+func ParseWithOptions(r io.Reader, opts Options) (*ClassFile, []Diagnostic, error) {
+	return parse(newErrReader(r, 0, ""), opts)
+}
+
+// ParseBytes parses a class file in the bytes.
+//
+// Unlike Parse, the ClassFile shares the memory with the bytes, which must not be modified while it is in use.
+// The Code attributes and the annotations are decoded on first access, or by DecodeAll.
+// Their problems are found only then: such an attribute is treated as an unrecognized attribute,
+// and Method.Decode, Field.Decode or DecodeAll returns the error.
+// In lenient mode, they are decoded while parsing to report their problems as diagnostics.
+func ParseBytes(data []byte) (*ClassFile, error) {
+	cf, _, err := ParseBytesWithOptions(data, Options{})
+	return cf, err
+}
+
+// ParseBytesWithOptions parses a class file in the bytes with the options, like ParseBytes and ParseWithOptions.
+func ParseBytesWithOptions(data []byte, opts Options) (*ClassFile, []Diagnostic, error) {
+	return parse(newBytesErrReader(data, 0, ""), opts)
+}
+
+// ParseReaderAt parses a class file of the size bytes in r.
+//
+// The Code attributes and the annotations are read and decoded on first access, or by DecodeAll,
+// so r must be readable while the ClassFile is in use. Their problems are found only then, as with ParseBytes.
+func ParseReaderAt(r io.ReaderAt, size int64) (*ClassFile, error) {
+	cf, _, err := ParseReaderAtWithOptions(r, size, Options{})
+	return cf, err
+}
+
+// ParseReaderAtWithOptions parses a class file of the size bytes in r with the options,
+// like ParseReaderAt and ParseWithOptions.
+func ParseReaderAtWithOptions(r io.ReaderAt, size int64, opts Options) (*ClassFile, []Diagnostic, error) {
+	return parse(newReaderAtErrReader(r, size, 0, ""), opts)
+}
+
+func parse(er *errReader, opts Options) (*ClassFile, []Diagnostic, error) {
+	er.r.limit = opts.Limits.maxBytes()
+	if er.r.bounded {
+		// the size of the input is known in advance
+		item(er, "ClassFile", func(e *errReader) bool {
+			if err := e.r.check(e.r.end - e.r.n); err != nil {
+				e.fail(err)
+				return false
+			}
+			return true
+		})
+	}
 
 	var magic [4]byte
-	item(&er, "maigc number", bytes(magic[:], match([]byte{0xCA, 0xFE, 0xBA, 0xBE})))
+	item(er, "magic", bytes(magic[:], magicNumber()))
 
-	var cf ClassFile
-	item(&er, "minor_version", integer(&cf.MinorVer))
-	item(&er, "major_version", integer(&cf.MajorVer))
+	cf := ClassFile{options: opts}
+	item(er, "minor_version", integer(&cf.MinorVer))
+	versions := []validator[uint16]{majorVersion()}
+	if opts.MaxMajorVersion != 0 {
+		versions = append(versions, maxMajorVersion(opts.MaxMajorVersion))
+	}
+	item(er, "major_version", integer(&cf.MajorVer, versions...))
+	if er.err == nil {
+		// minor_version depends on major_version, which follows it
+		er.name, er.offset = "minor_version", 4
+		validate(er, cf.MinorVer, minorVersion(cf.MajorVer))
+	}
 
-	if item(&er, "constant_pool_count", integer(&cf.constantPoolCount)) {
-		cf.ConstantPool = make([]cpInfo, cf.constantPoolCount-1)
-		item(&er, "constant_pool", entries(cf.ConstantPool[:], parseCpInfo))
+	if item(er, "constant_pool_count", integer(&cf.constantPoolCount, min[uint16](1))) {
+		cf.ConstantPool = makeTable[Constant](er, &cf, int(cf.constantPoolCount-1))
+		item(er, "constant_pool", constantPool(&cf, cf.ConstantPool))
 	}
 
-	var accessFlag uint16
-	if item(&er, "access_flags", integer(&accessFlag)) {
-		cf.AccessFlags = AccessFlags(accessFlag)
+	if item(er, "access_flags", integer(&cf.AccessFlags)) {
+		validate(er, cf.AccessFlags, classAccessFlags())
+		cf.tolerate(er, SeverityError)
 	}
 
-	item(&er, "thisClass", integer(&cf.thisClass, constantPoolStructure[uint16, *constantClass](&cf)))
-	if item(&er, "superClass", integer(&cf.superClass)) {
+	item(er, "thisClass", integer(&cf.thisClass, constantPoolStructure[uint16, *ConstantClass](&cf)))
+	if item(er, "superClass", integer(&cf.superClass)) {
 		if cf.superClass != 0 {
-			validate(&er, constantPoolStructure[uint16, *constantClass](&cf))
+			validate(er, cf.superClass, constantPoolStructure[uint16, *ConstantClass](&cf))
 		}
 	}
 
-	if item(&er, "interfaceCount", integer(&cf.interfaceCount)) {
+	if item(er, "interfaceCount", integer(&cf.interfaceCount)) {
 		if 0 < cf.interfaceCount {
-			cf.interfaces = make([]uint16, cf.interfaceCount-1)
-			item(&er, "interfaces", entries(cf.interfaces, func(er *errReader) uint16 {
+			cf.interfaces = makeTable[uint16](er, &cf, int(cf.interfaceCount))
+			item(er, "interfaces", entries(cf.interfaces, func(er *errReader) uint16 {
 				var idx uint16
-				item(er, "interfaces", integer(&idx, constantPoolStructure[uint16, *constantClass](&cf)))
+				item(er, "interfaces", integer(&idx, constantPoolStructure[uint16, *ConstantClass](&cf)))
 				return idx
 			}))
 		}
 	}
 
-	if item(&er, "fieldsCount", integer(&cf.fieldsCount)) {
-		cf.fields = make([]fieldInfo, cf.fieldsCount)
-		item(&er, "fields", entries(cf.fields, func(er *errReader) fieldInfo {
+	if item(er, "fieldsCount", integer(&cf.fieldsCount)) {
+		cf.fields = makeTable[fieldInfo](er, &cf, int(cf.fieldsCount))
+		item(er, "fields", entries(cf.fields, func(er *errReader) fieldInfo {
 			return parseField(er, &cf)
 		}))
 	}
 
-	return &cf, er.err
+	if item(er, "methodsCount", integer(&cf.methodsCount)) {
+		cf.methods = makeTable[methodInfo](er, &cf, int(cf.methodsCount))
+		item(er, "methods", entries(cf.methods, func(er *errReader) methodInfo {
+			return parseMethod(er, &cf)
+		}))
+	}
+
+	if item(er, "attributesCount", integer(&cf.attributesCount)) {
+		cf.attributes = makeTable[attributeInfo](er, &cf, int(cf.attributesCount))
+		item(er, "attributes", entries(cf.attributes, func(er *errReader) attributeInfo {
+			return parseClassAttributeInfo(er, &cf)
+		}))
+	}
+
+	item(er, "attributes", eof)
+
+	// the references in constant_pool are checked after the BootstrapMethods attribute is read
+	if er.err == nil && !item(er, "constant_pool", constantPoolReferences(&cf)) {
+		cf.tolerate(er, SeverityError)
+	}
+
+	if er.err != nil && opts.Lenient {
+		cf.diagnose(SeverityFatal, er.err)
+		cf.trimPartial()
+	}
+	return &cf, cf.diagnostics, er.err
 }
 
 func (c *ClassFile) Version() string {
@@ -84,51 +230,254 @@ func (c *ClassFile) Version() string {
 }
 
 func (c *ClassFile) ThisClassName() string {
-	class := getCpinfo[*constantClass](c, c.thisClass)
-	utf8 := getCpinfo[*constantUtf8](c, class.nameIndex)
-	return utf8.String()
+	return c.className(c.thisClass)
 }
 
+// SuperClassName returns the name of the direct superclass, or an empty string if super_class is 0,
+// which is the case only for java/lang/Object and module-info.
 func (c *ClassFile) SuperClassName() string {
-	class := getCpinfo[*constantClass](c, c.superClass)
-	utf8 := getCpinfo[*constantUtf8](c, class.nameIndex)
-	return utf8.String()
+	if c.superClass == 0 {
+		return ""
+	}
+	return c.className(c.superClass)
 }
 
 func (c *ClassFile) InterfaceNames() []string {
 	if c.interfaceCount == 0 {
 		return nil
 	}
-	names := make([]string, c.interfaceCount-1)
+	names := make([]string, c.interfaceCount)
 	for i, idx := range c.interfaces {
-		class := getCpinfo[*constantClass](c, idx)
-		utf8 := getCpinfo[*constantUtf8](c, class.nameIndex)
-		names[i] = utf8.String()
+		names[i] = c.className(idx)
 	}
 	return names
 }
 
-func (c *ClassFile) lookupConstantPool(i uint16) (cpInfo, bool) {
-	// The constant_pool table is indexed from 1 to constant_pool_count - 1
-	if i < 1 {
+// Fields returns the fields declared by this class or interface.
+func (c *ClassFile) Fields() []*Field {
+	fields := make([]*Field, len(c.fields))
+	for i := range c.fields {
+		fields[i] = &Field{cf: c, info: &c.fields[i]}
+	}
+	return fields
+}
+
+// Methods returns the methods declared by this class or interface.
+func (c *ClassFile) Methods() []*Method {
+	methods := make([]*Method, len(c.methods))
+	for i := range c.methods {
+		methods[i] = &Method{cf: c, info: &c.methods[i]}
+	}
+	return methods
+}
+
+// Attributes returns the attributes of the class in the order they appear in the class file.
+func (c *ClassFile) Attributes() []Attribute {
+	attrs := make([]Attribute, len(c.attributes))
+	for i, a := range c.attributes {
+		attrs[i] = resolveAttribute(a)
+	}
+	return attrs
+}
+
+// TypeAnnotations returns the type annotations in the class declaration,
+// such as on its type parameters, superclass or superinterfaces.
+func (c *ClassFile) TypeAnnotations() []TypeAnnotation {
+	return typeAnnotations(c, c.attributes)
+}
+
+// Annotations returns the annotations on the class declaration, both visible and invisible at run time.
+func (c *ClassFile) Annotations() []Annotation {
+	return annotations(c, c.attributes)
+}
+
+// HasAnnotation reports whether the class is annotated by the annotation interface of the field descriptor,
+// such as "Ljava/lang/Deprecated;".
+func (c *ClassFile) HasAnnotation(typ string) bool {
+	_, ok := findAnnotation(c, c.attributes, typ)
+	return ok
+}
+
+// AnnotationValue returns the value of the element of the name in the annotation of the type on the class.
+// It reports false if the element is not given explicitly.
+func (c *ClassFile) AnnotationValue(typ, name string) (v any, ok bool) {
+	return annotationValue(c, c.attributes, typ, name)
+}
+
+// SourceFile returns the name of the source file from which this class file was compiled,
+// or an empty string if the class file has no SourceFile attribute.
+func (c *ClassFile) SourceFile() string {
+	attr, ok := findAttribute[*attributeSourceFile](c.attributes)
+	if !ok {
+		return ""
+	}
+	return c.utf8(attr.sourceFileIndex)
+}
+
+// Signature returns the generic declaration of the class from its Signature attribute.
+// ok is false if the class file has no Signature attribute,
+// or if the signature is invalid, which a ClassFile parsed in lenient mode can have.
+func (c *ClassFile) Signature() (sig *signature.ClassSignature, ok bool) {
+	attr, ok := findAttribute[*attributeSignature](c.attributes)
+	if !ok {
 		return nil, false
-	} else if c.constantPoolCount < i {
+	}
+	sig, err := signature.ParseClass(c.utf8(attr.signatureIndex))
+	if err != nil {
 		return nil, false
 	}
-	return c.ConstantPool[i-1], true
+	return sig, true
+}
+
+// SourceDebugExtension returns the extended debugging information, such as an SMAP of JSR-45,
+// or an empty string if the class file has no SourceDebugExtension attribute.
+func (c *ClassFile) SourceDebugExtension() string {
+	attr, ok := findAttribute[*attributeSourceDebugExtension](c.attributes)
+	if !ok {
+		return ""
+	}
+	return string(attr.debugExtension)
+}
+
+// InnerClass is an entry of the InnerClasses attribute.
+type InnerClass struct {
+	// InnerClassName is the binary name of the nested class.
+	InnerClassName string
+	// OuterClassName is the binary name of the class of which the nested class is a member,
+	// or an empty string if it is a top-level, local or anonymous class.
+	OuterClassName string
+	// InnerName is the simple name of the nested class, or an empty string if it is anonymous.
+	InnerName   string
+	AccessFlags InnerClassAccessFlags
+}
+
+// InnerClasses returns the nested classes recorded in the InnerClasses attribute.
+func (c *ClassFile) InnerClasses() []InnerClass {
+	attr, ok := findAttribute[*attributeInnerClasses](c.attributes)
+	if !ok {
+		return nil
+	}
+	classes := make([]InnerClass, len(attr.classes))
+	for i, e := range attr.classes {
+		classes[i].InnerClassName = c.className(e.innerClassInfoIndex)
+		if e.outerClassInfoIndex != 0 {
+			classes[i].OuterClassName = c.className(e.outerClassInfoIndex)
+		}
+		if e.innerNameIndex != 0 {
+			classes[i].InnerName = c.utf8(e.innerNameIndex)
+		}
+		classes[i].AccessFlags = e.innerClassAccessFlags
+	}
+	return classes
+}
+
+// EnclosingMethod is the innermost class and method that enclose a local or anonymous class.
+type EnclosingMethod struct {
+	ClassName string
+	// MethodName and MethodDescriptor are empty if the class is not immediately enclosed
+	// by a method or constructor, e.g. it is declared in an instance initializer.
+	MethodName       string
+	MethodDescriptor string
+}
+
+// EnclosingMethod returns the EnclosingMethod attribute of the class.
+// ok is false if the class is not a local or anonymous class.
+func (c *ClassFile) EnclosingMethod() (m EnclosingMethod, ok bool) {
+	attr, ok := findAttribute[*attributeEnclosingMethod](c.attributes)
+	if !ok {
+		return m, false
+	}
+	m.ClassName = c.className(attr.classIndex)
+	if attr.methodIndex != 0 {
+		if nt, err := lookupCpinfo[*ConstantNameAndType](c, attr.methodIndex); err == nil {
+			m.MethodName, m.MethodDescriptor = nt.Name(), nt.Descriptor()
+		}
+	}
+	return m, true
+}
+
+// NestHost returns the name of the nest host of the class,
+// or an empty string if the class file has no NestHost attribute.
+func (c *ClassFile) NestHost() string {
+	attr, ok := findAttribute[*attributeNestHost](c.attributes)
+	if !ok {
+		return ""
+	}
+	return c.className(attr.hostClassIndex)
+}
+
+// NestMembers returns the names of the classes which are members of the nest hosted by the class.
+func (c *ClassFile) NestMembers() []string {
+	attr, ok := findAttribute[*attributeNestMembers](c.attributes)
+	if !ok {
+		return nil
+	}
+	return c.classNames(attr.classes)
+}
+
+// PermittedSubclasses returns the names of the classes which may directly extend or implement the sealed class.
+func (c *ClassFile) PermittedSubclasses() []string {
+	attr, ok := findAttribute[*attributePermittedSubclasses](c.attributes)
+	if !ok {
+		return nil
+	}
+	return c.classNames(attr.classes)
+}
+
+// className returns the name of the CONSTANT_Class_info structure, or an empty string if it is invalid,
+// which a ClassFile parsed in lenient mode can refer to.
+func (c *ClassFile) className(i uint16) string {
+	class, err := lookupCpinfo[*ConstantClass](c, i)
+	if err != nil {
+		return ""
+	}
+	return class.Name()
+}
+
+// utf8 returns the string of the CONSTANT_Utf8_info structure, or an empty string if it is invalid,
+// which a ClassFile parsed in lenient mode can refer to.
+func (c *ClassFile) utf8(i uint16) string {
+	s, err := lookupCpinfo[*ConstantUtf8](c, i)
+	if err != nil {
+		return ""
+	}
+	return s.String()
+}
+
+func (c *ClassFile) classNames(indexes []uint16) []string {
+	names := make([]string, len(indexes))
+	for i, idx := range indexes {
+		names[i] = c.className(idx)
+	}
+	return names
+}
+
+// ConstantAt returns the entry of the constant_pool table at the index.
+// ok is false if the index is out of the table or refers to an unusable entry.
+func (c *ClassFile) ConstantAt(i uint16) (entry Constant, ok bool) {
+	return c.lookupConstantPool(i)
 }
 
-func getCpinfo[T cpInfo](cf *ClassFile, i uint16) T {
-	e := must(cf.lookupConstantPool(i))
-	return e.(T)
+func (c *ClassFile) lookupConstantPool(i uint16) (Constant, bool) {
+	// The constant_pool table is indexed from 1 to constant_pool_count - 1
+	if i < 1 {
+		return nil, false
+	} else if len(c.ConstantPool) < int(i) {
+		return nil, false
+	}
+	e := c.ConstantPool[i-1]
+	if _, ok := e.(*ConstantUnusable); ok {
+		return nil, false
+	}
+	return e, true
 }
 
 var (
-	errNotFoundConstantPoolEntry    = errors.New("not found constant pool entry")
-	errInvalidConstantPoolStructure = errors.New("invalid constant pool entry's structure")
+	errNotFoundConstantPoolEntry    = fmt.Errorf("%w: not found constant pool entry", ErrBadConstantPoolReference)
+	errInvalidConstantPoolStructure = fmt.Errorf("%w: invalid constant pool entry's structure", ErrBadConstantPoolReference)
 )
 
-func lookupCpinfo[T cpInfo](cf *ClassFile, i uint16) (entry T, err error) {
+func lookupCpinfo[T Constant](cf *ClassFile, i uint16) (entry T, err error) {
 	e, ok := cf.lookupConstantPool(i)
 	if !ok {
 		return entry, errNotFoundConstantPoolEntry
@@ -139,10 +488,3 @@ func lookupCpinfo[T cpInfo](cf *ClassFile, i uint16) (entry T, err error) {
 	}
 	return entry, nil
 }
-
-func must[T any](v T, ok bool) T {
-	if !ok {
-		panic("must be true")
-	}
-	return v
-}
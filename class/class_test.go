@@ -1,7 +1,12 @@
 package class_test
 
 import (
+	"bytes"
+	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	. "github.com/thara/godiva/class"
@@ -113,4 +118,1824 @@ func TestParse(t *testing.T) {
 	assert.Equal(t, "HelloWorld", cf.ThisClassName())
 	assert.Equal(t, "java/lang/Object", cf.SuperClassName())
 	assert.Empty(t, cf.InterfaceNames())
+	assert.Equal(t, "HelloWorld.java", cf.SourceFile())
+
+	methods := cf.Methods()
+	if assert.Len(t, methods, 2) {
+		assert.Equal(t, "<init>", methods[0].Name())
+		assert.Equal(t, "()V", methods[0].Descriptor())
+		assert.Equal(t, MethodAccessFlagsPublic, methods[0].AccessFlags())
+		if assert.Len(t, methods[0].Attributes(), 1) {
+			assert.Equal(t, "Code", methods[0].Attributes()[0].Name())
+		}
+
+		assert.Equal(t, "main", methods[1].Name())
+		assert.Equal(t, "([Ljava/lang/String;)V", methods[1].Descriptor())
+		assert.Equal(t, MethodAccessFlagsPublic|MethodAccessFlagsStatic, methods[1].AccessFlags())
+		if assert.Len(t, methods[1].Attributes(), 1) {
+			assert.Equal(t, "Code", methods[1].Attributes()[0].Name())
+		}
+
+		code := methods[1].Code()
+		if assert.NotNil(t, code) {
+			assert.Equal(t, uint16(2), code.MaxStack())
+			assert.Equal(t, uint16(1), code.MaxLocals())
+			assert.Equal(t, []byte{0xb2, 0x00, 0x07, 0x12, 0x0d, 0xb6, 0x00, 0x0f, 0xb1}, code.Bytecode())
+			assert.Empty(t, code.ExceptionTable())
+			if assert.Len(t, code.Attributes(), 1) {
+				assert.Equal(t, "LineNumberTable", code.Attributes()[0].Name())
+			}
+			assert.Equal(t, []LineNumber{{StartPC: 0, Line: 3}, {StartPC: 8, Line: 4}}, code.LineNumberTable())
+		}
+	}
+}
+
+func TestParseMethods(t *testing.T) {
+	b := newClassBuilder("Sample", "java/lang/Object")
+	b.interfaces = []uint16{b.class("java/lang/Runnable")}
+	b.method(0x0401, "run", "()V")
+	b.method(0x0009, "read", "(Ljava/lang/String;I)V",
+		b.attribute("Exceptions", u2(1), u2(b.class("java/io/IOException"))),
+		b.attribute("MethodParameters", u1(2), u2(b.utf8("path")), u2(0x0010), u2(0), u2(0)),
+		b.attribute("Deprecated"),
+	)
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"java/lang/Runnable"}, cf.InterfaceNames())
+
+	methods := cf.Methods()
+	require.Len(t, methods, 2)
+
+	assert.Equal(t, "run", methods[0].Name())
+	assert.Equal(t, "()V", methods[0].Descriptor())
+	assert.Equal(t, MethodAccessFlagsPublic|MethodAccessFlagsAbstract, methods[0].AccessFlags())
+	assert.Empty(t, methods[0].Attributes())
+
+	assert.Equal(t, "read", methods[1].Name())
+	assert.Equal(t, "(Ljava/lang/String;I)V", methods[1].Descriptor())
+	var names []string
+	for _, a := range methods[1].Attributes() {
+		names = append(names, a.Name())
+	}
+	assert.Equal(t, []string{"Exceptions", "MethodParameters", "Deprecated"}, names)
+}
+
+func TestParseClassAttributes(t *testing.T) {
+	b := newClassBuilder("Outer$1", "java/lang/Object")
+	b.attributes = [][]byte{
+		b.attribute("SourceFile", u2(b.utf8("Outer.java"))),
+		b.attribute("InnerClasses", u2(2),
+			u2(b.class("Outer$1")), u2(0), u2(0), u2(0x0000),
+			u2(b.class("Outer$Shape")), u2(b.class("Outer")), u2(b.utf8("Shape")), u2(0x0609),
+		),
+		b.attribute("EnclosingMethod", u2(b.class("Outer")), u2(b.nameAndType("run", "()V"))),
+		b.attribute("NestHost", u2(b.class("Outer"))),
+		b.attribute("NestMembers", u2(1), u2(b.class("Outer$Shape"))),
+		b.attribute("PermittedSubclasses", u2(2), u2(b.class("Outer$Circle")), u2(b.class("Outer$Square"))),
+		b.attribute("SourceDebugExtension", []byte("SMAP\nOuter.java\n")),
+	}
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Outer.java", cf.SourceFile())
+	assert.Equal(t, []InnerClass{
+		{InnerClassName: "Outer$1"},
+		{InnerClassName: "Outer$Shape", OuterClassName: "Outer", InnerName: "Shape", AccessFlags: 0x0609},
+	}, cf.InnerClasses())
+
+	m, ok := cf.EnclosingMethod()
+	assert.True(t, ok)
+	assert.Equal(t, EnclosingMethod{ClassName: "Outer", MethodName: "run", MethodDescriptor: "()V"}, m)
+
+	assert.Equal(t, "Outer", cf.NestHost())
+	assert.Equal(t, []string{"Outer$Shape"}, cf.NestMembers())
+	assert.Equal(t, []string{"Outer$Circle", "Outer$Square"}, cf.PermittedSubclasses())
+	assert.Equal(t, "SMAP\nOuter.java\n", cf.SourceDebugExtension())
+	assert.Len(t, cf.Attributes(), 7)
+
+	_, err = Parse(bytes.NewReader(append(b.build(), 0x00)))
+	assert.Error(t, err)
+}
+
+func TestParseCode(t *testing.T) {
+	b := newClassBuilder("Sample", "java/lang/Object")
+	b.method(0x0401, "run", "()V")
+	b.method(0x0009, "call", "()V",
+		b.attribute("Code",
+			u2(1), u2(1),
+			u4(8), []byte{0x00, 0xb1, 0x4b, 0xb1, 0x4b, 0xb1, 0x00, 0x00},
+			u2(2),
+			u2(0), u2(2), u2(2), u2(b.class("java/lang/Exception")),
+			u2(0), u2(2), u2(4), u2(0),
+			u2(1), b.attribute("LineNumberTable", u2(1), u2(0), u2(3)),
+		),
+	)
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	methods := cf.Methods()
+	require.Len(t, methods, 2)
+	assert.Nil(t, methods[0].Code())
+
+	code := methods[1].Code()
+	require.NotNil(t, code)
+	assert.Equal(t, uint16(1), code.MaxStack())
+	assert.Equal(t, uint16(1), code.MaxLocals())
+	assert.Len(t, code.Bytecode(), 8)
+	assert.Equal(t, []ExceptionHandler{
+		{StartPC: 0, EndPC: 2, HandlerPC: 2, CatchType: "java/lang/Exception"},
+		{StartPC: 0, EndPC: 2, HandlerPC: 4},
+	}, code.ExceptionTable())
+	if assert.Len(t, code.Attributes(), 1) {
+		assert.Equal(t, "LineNumberTable", code.Attributes()[0].Name())
+	}
+}
+
+func TestStackMapTable(t *testing.T) {
+	b := newClassBuilder("Sample", "java/lang/Object")
+	code := make([]byte, 40)
+	code[len(code)-1] = 0xb1
+	b.method(0x0009, "call", "(I)V",
+		b.attribute("Code",
+			u2(3), u2(4),
+			u4(uint32(len(code))), code,
+			u2(0),
+			u2(1), b.attribute("StackMapTable", u2(6),
+				u1(3),
+				u1(64+2), u1(1),
+				u1(252), u2(4), u1(7), u2(b.class("java/lang/String")),
+				u1(249), u2(5),
+				u1(247), u2(1), u1(8), u2(10),
+				u1(255), u2(7), u2(2), u1(6), u1(4), u2(1), u1(5),
+			),
+		),
+	)
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	frames := cf.Methods()[0].Code().StackMapTable()
+	assert.Equal(t, []StackMapFrame{
+		{Kind: FrameSame, FrameType: 3, OffsetDelta: 3, Offset: 3},
+		{Kind: FrameSameLocals1StackItem, FrameType: 66, OffsetDelta: 2, Offset: 6,
+			Stack: []VerificationType{{Tag: VerificationInteger}}},
+		{Kind: FrameAppend, FrameType: 252, OffsetDelta: 4, Offset: 11,
+			Locals: []VerificationType{{Tag: VerificationObject, ClassName: "java/lang/String"}}},
+		{Kind: FrameChop, FrameType: 249, OffsetDelta: 5, Offset: 17, ChoppedLocals: 2},
+		{Kind: FrameSameLocals1StackItemExtended, FrameType: 247, OffsetDelta: 1, Offset: 19,
+			Stack: []VerificationType{{Tag: VerificationUninitialized, Offset: 10}}},
+		{Kind: FrameFull, FrameType: 255, OffsetDelta: 7, Offset: 27,
+			Locals: []VerificationType{{Tag: VerificationUninitializedThis}, {Tag: VerificationLong}},
+			Stack:  []VerificationType{{Tag: VerificationNull}}},
+	}, frames)
+	assert.Equal(t, "class java/lang/String", frames[2].Locals[0].String())
+	assert.Equal(t, "chop_frame", frames[3].Kind.String())
+}
+
+func TestDebugTables(t *testing.T) {
+	sample := func(signature string) []byte {
+		b := newClassBuilder("Sample", "java/lang/Object")
+		code := make([]byte, 20)
+		code[len(code)-1] = 0xb1
+		b.method(0x0009, "call", "(Ljava/util/List;)V",
+			b.attribute("Code",
+				u2(2), u2(3),
+				u4(uint32(len(code))), code,
+				u2(0),
+				u2(3),
+				b.attribute("LineNumberTable", u2(3), u2(0), u2(10), u2(12), u2(10), u2(6), u2(11)),
+				b.attribute("LocalVariableTable", u2(3),
+					u2(0), u2(20), u2(b.utf8("list")), u2(b.utf8("Ljava/util/List;")), u2(0),
+					u2(4), u2(16), u2(b.utf8("i")), u2(b.utf8("I")), u2(2),
+					u2(6), u2(6), u2(b.utf8("s")), u2(b.utf8("Ljava/lang/String;")), u2(1),
+				),
+				b.attribute("LocalVariableTypeTable", u2(1),
+					u2(0), u2(20), u2(b.utf8("list")), u2(b.utf8(signature)), u2(0),
+				),
+			),
+		)
+		return b.build()
+	}
+
+	cf, err := Parse(bytes.NewReader(sample("Ljava/util/List<Ljava/lang/String;>;")))
+	require.NoError(t, err)
+	c := cf.Methods()[0].Code()
+
+	line, ok := c.LineForPC(0)
+	assert.True(t, ok)
+	assert.Equal(t, 10, line)
+	line, ok = c.LineForPC(7)
+	assert.True(t, ok)
+	assert.Equal(t, 11, line)
+	line, ok = c.LineForPC(19)
+	assert.True(t, ok)
+	assert.Equal(t, 10, line)
+	_, ok = c.LineForPC(20)
+	assert.False(t, ok)
+
+	assert.Equal(t, []PCRange{{Start: 0, End: 6}, {Start: 12, End: 20}}, c.PCRangesForLine(10))
+	assert.Equal(t, []PCRange{{Start: 6, End: 12}}, c.PCRangesForLine(11))
+	assert.Empty(t, c.PCRangesForLine(12))
+
+	locals := c.LocalsAt(8)
+	if assert.Len(t, locals, 3) {
+		assert.Equal(t, LocalVariable{StartPC: 0, Length: 20, Name: "list", Descriptor: "Ljava/util/List;",
+			Signature: "Ljava/util/List<Ljava/lang/String;>;", Index: 0}, locals[0])
+		assert.Equal(t, "s", locals[1].Name)
+		assert.Equal(t, "i", locals[2].Name)
+	}
+	assert.Len(t, c.LocalsAt(2), 1)
+	assert.Len(t, c.LocalsAt(12), 2)
+
+	// the signature of a local variable is a field signature
+	for _, signature := range []string{"Ljava/util/List<Ljava/lang/String;>", "(I)V", "V"} {
+		_, err = Parse(bytes.NewReader(sample(signature)))
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe, signature) {
+			assert.Equal(t, "methods[0].attributes[0].attributes[2].local_variable_type_table[0].signature_index", pe.Path)
+		}
+	}
+}
+
+func TestModule(t *testing.T) {
+	b := newClassBuilder("module-info", "")
+	b.accessFlags = 0x8000
+	b.attributes = [][]byte{
+		b.attribute("Module",
+			u2(b.module("com.example.app")), u2(0x0020), u2(b.utf8("1.0")),
+			u2(2),
+			u2(b.module("java.base")), u2(0x8000), u2(b.utf8("18")),
+			u2(b.module("java.logging")), u2(0x0020), u2(0),
+			u2(2),
+			u2(b.pkg("com/example/api")), u2(0), u2(0),
+			u2(b.pkg("com/example/spi")), u2(0), u2(1), u2(b.module("com.example.plugin")),
+			u2(1),
+			u2(b.pkg("com/example/internal")), u2(0), u2(0),
+			u2(1), u2(b.class("com/example/spi/Plugin")),
+			u2(1),
+			u2(b.class("com/example/spi/Plugin")), u2(2), u2(b.class("com/example/impl/A")), u2(b.class("com/example/impl/B")),
+		),
+		b.attribute("ModulePackages", u2(2), u2(b.pkg("com/example/api")), u2(b.pkg("com/example/impl"))),
+		b.attribute("ModuleMainClass", u2(b.class("com/example/Main"))),
+	}
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	assert.Equal(t, "module-info", cf.ThisClassName())
+	// module-info has no superclass
+	assert.Equal(t, "", cf.SuperClassName())
+
+	assert.Equal(t, &ModuleDescriptor{
+		Name:    "com.example.app",
+		Flags:   0x0020,
+		Version: "1.0",
+		Requires: []ModuleRequires{
+			{Module: "java.base", Flags: 0x8000, Version: "18"},
+			{Module: "java.logging", Flags: 0x0020},
+		},
+		Exports: []ModuleExports{
+			{Package: "com/example/api"},
+			{Package: "com/example/spi", To: []string{"com.example.plugin"}},
+		},
+		Opens:     []ModuleOpens{{Package: "com/example/internal"}},
+		Uses:      []string{"com/example/spi/Plugin"},
+		Provides:  []ModuleProvides{{Service: "com/example/spi/Plugin", With: []string{"com/example/impl/A", "com/example/impl/B"}}},
+		Packages:  []string{"com/example/api", "com/example/impl"},
+		MainClass: "com/example/Main",
+	}, cf.Module())
+
+	f, err := os.Open("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+	defer f.Close()
+	cf, err = Parse(f)
+	require.NoError(t, err)
+	assert.Nil(t, cf.Module())
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	b := newClassBuilder("Sample", "java/lang/Object")
+	nonNull := b.utf8("Ljavax/annotation/Nonnull;")
+	b.attributes = [][]byte{
+		b.attribute("RuntimeVisibleTypeAnnotations", u2(1),
+			u1(0x10), u2(65535), u1(0), u2(nonNull), u2(0),
+		),
+	}
+	b.field(0x0002, "names", "Ljava/util/List;",
+		b.attribute("RuntimeInvisibleAnnotations", u2(1), u2(b.utf8("Ljava/lang/Deprecated;")), u2(0)),
+		b.attribute("RuntimeInvisibleTypeAnnotations", u2(1),
+			u1(0x13), u1(1), u1(3), u1(0), u2(nonNull), u2(1), u2(b.utf8("value")), u1('s'), u2(b.utf8("x")),
+		),
+	)
+	code := []byte{0x03, 0x3c, 0xb1}
+	b.method(0x0401, "value", "()I",
+		b.attribute("AnnotationDefault", u1('I'), u2(b.integer(42))),
+	)
+	b.method(0x0001, "call", "(Ljava/lang/String;)V",
+		b.attribute("Code",
+			u2(1), u2(3),
+			u4(uint32(len(code))), code,
+			u2(0),
+			u2(1), b.attribute("RuntimeVisibleTypeAnnotations", u2(1),
+				u1(0x40), u2(1), u2(2), u2(1), u2(2), u1(0), u2(nonNull), u2(0),
+			),
+		),
+		b.attribute("RuntimeVisibleParameterAnnotations", u1(1), u2(1), u2(nonNull), u2(0)),
+		b.attribute("RuntimeVisibleTypeAnnotations", u2(1),
+			u1(0x16), u1(0), u1(0), u2(nonNull), u2(0),
+		),
+	)
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	assert.Equal(t, []TypeAnnotation{
+		{TargetType: TargetClassExtends, Target: TypeAnnotationTarget{SupertypeIndex: 65535}, Annotation: Annotation{Type: "Ljavax/annotation/Nonnull;", Visible: true}},
+	}, withoutSpans(cf.TypeAnnotations()))
+
+	fields := cf.Fields()
+	require.Len(t, fields, 1)
+	assert.Equal(t, "names", fields[0].Name())
+	assert.Equal(t, []TypeAnnotation{
+		{TargetType: TargetField, TypePath: []TypePathEntry{{Kind: TypePathTypeArgument}}, Annotation: Annotation{
+			Type:     "Ljavax/annotation/Nonnull;",
+			Elements: []AnnotationElement{{Name: "value", Value: "x"}},
+		}},
+	}, withoutSpans(fields[0].TypeAnnotations()))
+	// the type path is a copy
+	fields[0].TypeAnnotations()[0].TypePath[0].Kind = TypePathArray
+	assert.Equal(t, TypePathTypeArgument, fields[0].TypeAnnotations()[0].TypePath[0].Kind)
+
+	methods := cf.Methods()
+	require.Len(t, methods, 2)
+	assert.Equal(t, "AnnotationDefault", methods[0].Attributes()[0].Name())
+	assert.Equal(t, []TypeAnnotation{
+		{TargetType: TargetMethodFormalParameter, Annotation: Annotation{Type: "Ljavax/annotation/Nonnull;", Visible: true}},
+	}, withoutSpans(methods[1].TypeAnnotations()))
+	assert.Equal(t, []TypeAnnotation{
+		{TargetType: TargetLocalVariable, Target: TypeAnnotationTarget{LocalVariables: []LocalVariableTarget{{StartPC: 2, Length: 1, Index: 2}}}, Annotation: Annotation{Type: "Ljavax/annotation/Nonnull;", Visible: true}},
+	}, withoutSpans(methods[1].Code().TypeAnnotations()))
+
+	// a field cannot have type annotations on a method return type
+	b = newClassBuilder("Sample", "java/lang/Object")
+	b.field(0x0002, "name", "Ljava/lang/String;",
+		b.attribute("RuntimeVisibleTypeAnnotations", u2(1), u1(0x14), u1(0), u2(b.utf8("Ljavax/annotation/Nonnull;")), u2(0)),
+	)
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.Error(t, err)
+}
+
+func TestAnnotations(t *testing.T) {
+	sign, err := os.ReadFile("../testdata/Sign.class")
+	require.NoError(t, err)
+	for name, parse := range map[string]func() (*ClassFile, error){
+		"Parse":      func() (*ClassFile, error) { return Parse(bytes.NewReader(sign)) },
+		"ParseBytes": func() (*ClassFile, error) { return ParseBytes(sign) },
+	} {
+		t.Run("Sign/"+name, func(t *testing.T) {
+			cf, err := parse()
+			require.NoError(t, err)
+
+			// the annotations of Maven are retained only in the class file, not at run time
+			mojo := "Lorg/apache/maven/plugins/annotations/Mojo;"
+			as := cf.Annotations()
+			require.Len(t, as, 1)
+			assert.Equal(t, mojo, as[0].Type)
+			assert.False(t, as[0].Visible)
+			assert.Equal(t, []AnnotationElement{
+				{Name: "name", Value: "sign"},
+				{Name: "defaultPhase", Value: EnumConstant{Type: "Lorg/apache/maven/plugins/annotations/LifecyclePhase;", Name: "PACKAGE"}},
+			}, as[0].Elements)
+			assert.True(t, cf.HasAnnotation(mojo))
+			v, ok := cf.AnnotationValue(mojo, "name")
+			assert.True(t, ok)
+			assert.Equal(t, "sign", v)
+			// the elements omitted in the source code are not recorded
+			_, ok = cf.AnnotationValue(mojo, "threadSafe")
+			assert.False(t, ok)
+
+			parameter := "Lorg/apache/maven/plugins/annotations/Parameter;"
+			field := cf.Fields()[0]
+			assert.Equal(t, "inputJar", field.Name())
+			as = field.Annotations()
+			require.Len(t, as, 1)
+			assert.Equal(t, parameter, as[0].Type)
+			assert.Equal(t, []AnnotationElement{
+				{Name: "property", Value: "input-jar"},
+				{Name: "required", Value: true},
+			}, as[0].Elements)
+			assert.True(t, field.HasAnnotation(parameter))
+			assert.False(t, field.HasAnnotation(mojo))
+			v, ok = field.AnnotationValue(parameter, "required")
+			assert.True(t, ok)
+			assert.Equal(t, true, v)
+
+			for _, m := range cf.Methods() {
+				assert.Empty(t, m.Annotations(), m.Name())
+			}
+		})
+	}
+
+	// none of the compiled classes in testdata has the visible, parameter or numeric annotations,
+	// nested annotations nor default values
+	b := newClassBuilder("Sample", "java/lang/Object")
+	deprecated := b.utf8("Ljava/lang/Deprecated;")
+	b.attributes = [][]byte{
+		b.attribute("RuntimeVisibleAnnotations", u2(1), u2(deprecated), u2(1),
+			u2(b.utf8("since")), u1('s'), u2(b.utf8("9")),
+		),
+	}
+	b.field(0x0002, "limit", "J",
+		b.attribute("RuntimeInvisibleAnnotations", u2(1), u2(b.utf8("LLimits;")), u2(9),
+			u2(b.utf8("b")), u1('B'), u2(b.integer(-1)),
+			u2(b.utf8("c")), u1('C'), u2(b.integer('x')),
+			u2(b.utf8("i")), u1('I'), u2(b.integer(42)),
+			u2(b.utf8("s")), u1('S'), u2(b.integer(-2)),
+			u2(b.utf8("z")), u1('Z'), u2(b.integer(1)),
+			u2(b.utf8("even")), u1('Z'), u2(b.integer(2)),
+			u2(b.utf8("j")), u1('J'), u2(b.long(1<<40)),
+			u2(b.utf8("f")), u1('F'), u2(b.float(1.5)),
+			u2(b.utf8("d")), u1('D'), u2(b.double(2.5)),
+		),
+	)
+	b.method(0x0001, "run", "(Ljava/lang/String;I)V",
+		b.attribute("RuntimeVisibleAnnotations", u2(2),
+			u2(deprecated), u2(0),
+			u2(b.utf8("LRoute;")), u2(3),
+			u2(b.utf8("policy")), u1('e'), u2(b.utf8("Ljava/lang/annotation/RetentionPolicy;")), u2(b.utf8("RUNTIME")),
+			u2(b.utf8("types")), u1('['), u2(2), u1('c'), u2(b.utf8("Ljava/lang/String;")), u1('c'), u2(b.utf8("V")),
+			u2(b.utf8("nested")), u1('@'), u2(b.utf8("LNested;")), u2(1), u2(b.utf8("value")), u1('I'), u2(b.integer(42)),
+		),
+		b.attribute("RuntimeInvisibleParameterAnnotations", u1(2), u2(0), u2(1), u2(b.utf8("LNullable;")), u2(0)),
+		b.attribute("RuntimeVisibleParameterAnnotations", u1(1), u2(1), u2(b.utf8("LNonnull;")), u2(0)),
+	)
+	b.method(0x0401, "value", "()[Ljava/lang/String;",
+		b.attribute("AnnotationDefault", u1('['), u2(1), u1('s'), u2(b.utf8("a"))),
+	)
+	data := b.build()
+
+	for name, parse := range map[string]func() (*ClassFile, error){
+		"Parse":      func() (*ClassFile, error) { return Parse(bytes.NewReader(data)) },
+		"ParseBytes": func() (*ClassFile, error) { return ParseBytes(data) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			cf, err := parse()
+			require.NoError(t, err)
+
+			as := cf.Annotations()
+			require.Len(t, as, 1)
+			assert.True(t, as[0].Visible)
+			assert.NotZero(t, as[0].Span.Length)
+			assert.True(t, cf.HasAnnotation("Ljava/lang/Deprecated;"))
+			assert.False(t, cf.HasAnnotation("LRoute;"))
+			v, ok := cf.AnnotationValue("Ljava/lang/Deprecated;", "since")
+			assert.True(t, ok)
+			assert.Equal(t, "9", v)
+			_, ok = cf.AnnotationValue("Ljava/lang/Deprecated;", "forRemoval")
+			assert.False(t, ok)
+
+			field := cf.Fields()[0]
+			as = field.Annotations()
+			require.Len(t, as, 1)
+			assert.False(t, as[0].Visible)
+			assert.Equal(t, []AnnotationElement{
+				{Name: "b", Value: int8(-1)},
+				{Name: "c", Value: uint16('x')},
+				{Name: "i", Value: int32(42)},
+				{Name: "s", Value: int16(-2)},
+				{Name: "z", Value: true},
+				{Name: "even", Value: false},
+				{Name: "j", Value: int64(1 << 40)},
+				{Name: "f", Value: float32(1.5)},
+				{Name: "d", Value: float64(2.5)},
+			}, as[0].Elements)
+			assert.True(t, field.HasAnnotation("LLimits;"))
+
+			methods := cf.Methods()
+			run := methods[0]
+			assert.True(t, run.HasAnnotation("Ljava/lang/Deprecated;"))
+			v, ok = run.AnnotationValue("LRoute;", "policy")
+			assert.True(t, ok)
+			assert.Equal(t, EnumConstant{Type: "Ljava/lang/annotation/RetentionPolicy;", Name: "RUNTIME"}, v)
+			v, _ = run.AnnotationValue("LRoute;", "types")
+			assert.Equal(t, []any{ClassDescriptor("Ljava/lang/String;"), ClassDescriptor("V")}, v)
+			v, _ = run.AnnotationValue("LRoute;", "nested")
+			if nested, ok := v.(Annotation); assert.True(t, ok) {
+				assert.Equal(t, "LNested;", nested.Type)
+				assert.True(t, nested.Visible)
+				value, _ := nested.Value("value")
+				assert.Equal(t, int32(42), value)
+			}
+
+			params := run.ParameterAnnotations()
+			require.Len(t, params, 2)
+			require.Len(t, params[0], 1)
+			assert.Equal(t, "LNonnull;", params[0][0].Type)
+			assert.True(t, params[0][0].Visible)
+			require.Len(t, params[1], 1)
+			assert.Equal(t, "LNullable;", params[1][0].Type)
+			assert.False(t, params[1][0].Visible)
+
+			_, ok = run.AnnotationDefault()
+			assert.False(t, ok)
+			v, ok = methods[1].AnnotationDefault()
+			assert.True(t, ok)
+			assert.Equal(t, []any{"a"}, v)
+		})
+	}
+}
+
+// withoutSpans clears the spans of the type annotations to compare them by their contents.
+func withoutSpans(as []TypeAnnotation) []TypeAnnotation {
+	for i := range as {
+		as[i].Span, as[i].Annotation.Span = Span{}, Span{}
+	}
+	return as
+}
+
+func TestUnknownAttributes(t *testing.T) {
+	type scalaSig struct {
+		major, minor uint8
+		owner        string
+	}
+	unregister := RegisterAttribute(ContextClass, "TestScalaSig", func(r *AttributeReader) any {
+		return scalaSig{major: r.U1("major_version"), minor: r.U1("minor_version"), owner: r.ClassName("owner")}
+	})
+	t.Cleanup(unregister)
+
+	b := newClassBuilder("Sample", "java/lang/Object")
+	b.attributes = [][]byte{b.attribute("TestScalaSig", u1(5), u1(2), u2(b.class("Sample")))}
+	b.field(0x0002, "name", "Ljava/lang/String;", b.attribute("TestScalaSig", u1(0xFF)))
+	b.method(0x0401, "run", "()V", b.attribute("kotlin.Metadata", []byte("raw")))
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	attrs := cf.Attributes()
+	require.Len(t, attrs, 1)
+	if attr, ok := attrs[0].(*DecodedAttribute); assert.True(t, ok) {
+		assert.Equal(t, "TestScalaSig", attr.Name())
+		assert.Equal(t, scalaSig{major: 5, minor: 2, owner: "Sample"}, attr.Value())
+	}
+
+	// decoders are registered per context
+	if attr, ok := cf.Fields()[0].Attributes()[0].(*RawAttribute); assert.True(t, ok) {
+		assert.Equal(t, []byte{0xFF}, attr.Info())
+	}
+	if attr, ok := cf.Methods()[0].Attributes()[0].(*RawAttribute); assert.True(t, ok) {
+		assert.Equal(t, "kotlin.Metadata", attr.Name())
+		assert.Equal(t, []byte("raw"), attr.Info())
+	}
+
+	// the decoder must consume the whole info
+	b.attributes = [][]byte{b.attribute("TestScalaSig", u1(5), u1(2), u2(b.class("Sample")), u1(0))}
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.Error(t, err)
+	// the attribute is kept raw after the decoder is unregistered,
+	// and the unregister function of a replaced decoder does not remove the new one
+	b.attributes = [][]byte{b.attribute("TestScalaSig", u1(5), u1(2), u2(b.class("Sample")))}
+	unregister()
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	assert.IsType(t, &RawAttribute{}, cf.Attributes()[0])
+	t.Cleanup(RegisterAttribute(ContextClass, "TestScalaSig", func(r *AttributeReader) any { return r.Bytes("info", 4) }))
+	unregister()
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	assert.IsType(t, &DecodedAttribute{}, cf.Attributes()[0])
+}
+
+func TestLongAndDoubleConstants(t *testing.T) {
+	data, err := os.ReadFile("../testdata/NumberToJSON.class")
+	require.NoError(t, err)
+	cf, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// each long takes two entries, so that the following entries keep the indices written by javac
+	require.Len(t, cf.ConstantPool, 405)
+	assert.Equal(t, int64(math.MaxInt64), cf.ConstantPool[4-1].(*ConstantLong).Int64())
+	assert.IsType(t, &ConstantUnusable{}, cf.ConstantPool[5-1])
+	assert.IsType(t, &ConstantString{}, cf.ConstantPool[6-1])
+	assert.Equal(t, int64(0x7FF0000000000000), cf.ConstantPool[7-1].(*ConstantLong).Int64())
+	assert.IsType(t, &ConstantUnusable{}, cf.ConstantPool[8-1])
+	assert.Equal(t, "java/io/IOException", cf.ConstantPool[9-1].(*ConstantClass).Name())
+	for i := 12; i < 18; i += 2 {
+		assert.IsType(t, &ConstantLong{}, cf.ConstantPool[i-1])
+		assert.IsType(t, &ConstantUnusable{}, cf.ConstantPool[i])
+	}
+	assert.Equal(t, "org/webpki/jcs/NumberToJSON.DEBUG:Z", cf.ConstantPool[18-1].Symbolic())
+	assert.Equal(t, int64(math.MaxInt32), cf.ConstantPool[136-1].(*ConstantLong).Int64())
+	assert.Equal(t, "org/webpki/jcs/NumberToJSON.POW5_SPLIT:[[I", cf.ConstantPool[138-1].Symbolic())
+	assert.Equal(t, "add", cf.ConstantPool[405-1].(*ConstantUtf8).String())
+	assert.Equal(t, "org/webpki/jcs/NumberToJSON", cf.ThisClassName())
+
+	constants := map[string]any{}
+	for _, f := range cf.Fields() {
+		if v, ok := f.ConstantValue(); ok {
+			constants[f.Name()] = v
+		}
+	}
+	assert.Equal(t, int64(1<<52-1), constants["DOUBLE_MANTISSA_MASK"])
+	assert.Equal(t, int64(math.MaxInt64), constants["ZERO_PATTERN"])
+	assert.Equal(t, int64(0x7FF0000000000000), constants["INVALID_PATTERN"])
+	assert.Equal(t, int32(1023), constants["DOUBLE_EXPONENT_BIAS"])
+
+	data, err = os.ReadFile("../testdata/Download$DefaultDownloadProgressListener.class")
+	require.NoError(t, err)
+	cf, err = Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, cf.ConstantPool[15-1].(*ConstantDouble).Float64())
+	assert.IsType(t, &ConstantUnusable{}, cf.ConstantPool[16-1])
+	assert.Equal(t, "java/lang/Math.max:(II)I", cf.ConstantPool[17-1].Symbolic())
+	methods := cf.Methods()
+	require.Len(t, methods, 4)
+	assert.Equal(t, "calculateDownloadPercent", methods[3].Name())
+	// ldc2_w #15 and invokestatic #17 in the bytecode
+	code := methods[3].Code().Bytecode()
+	assert.Equal(t, []byte{0x14, 0, 15, 0x6b, 0x8e, 0xb8, 0, 17}, code[8:16])
+
+	// the entry following a long or double constant is unusable
+	b := newClassBuilder("Constants", "java/lang/Object")
+	b.attributes = [][]byte{b.attribute("SourceFile", u2(b.long(1<<40)+1))}
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.Error(t, err)
+
+	b = newClassBuilder("Constants", "java/lang/Object")
+	b.field(0x0019, "L", "J", b.attribute("ConstantValue", u2(b.long(1)+1)))
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.Error(t, err)
+}
+
+func TestNumericConstants(t *testing.T) {
+	data, err := os.ReadFile("../testdata/NumberToJSON.class")
+	require.NoError(t, err)
+	cf, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "78913", cf.ConstantPool[51-1].String())
+	assert.Equal(t, int32(78913), cf.ConstantPool[51-1].(*ConstantInteger).Int32())
+	assert.Equal(t, "9223372036854775807L", cf.ConstantPool[4-1].String())
+	assert.Equal(t, "4503599627370495L", cf.ConstantPool[14-1].String())
+	assert.Equal(t, int64(4503599627370495), cf.ConstantPool[14-1].(*ConstantLong).Int64())
+
+	data, err = os.ReadFile("../testdata/Download$DefaultDownloadProgressListener.class")
+	require.NoError(t, err)
+	cf, err = Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "100.0d", cf.ConstantPool[15-1].String())
+	assert.Equal(t, 100.0, cf.ConstantPool[15-1].(*ConstantDouble).Float64())
+
+	// the compiled classes in testdata have no float constants nor the values formatted specially
+	b := newClassBuilder("Constants", "java/lang/Object")
+	tests := []struct {
+		index uint16
+		want  string
+	}{
+		{b.integer(-42), "-42"},
+		{b.float(3.14), "3.14f"},
+		{b.float(1), "1.0f"},
+		{b.float(float32(math.Copysign(0, -1))), "-0.0f"},
+		{b.float(1e10), "1.0E10f"},
+		{b.float(float32(math.NaN())), "NaN"},
+		{b.float(float32(math.Inf(-1))), "-Infinity"},
+		{b.long(-9223372036854775808), "-9223372036854775808L"},
+		{b.double(0.1), "0.1d"},
+		{b.double(1234567.0), "1234567.0d"},
+		{b.double(12345678.0), "1.2345678E7d"},
+		{b.double(0.0001), "1.0E-4d"},
+		{b.double(math.Inf(1)), "Infinity"},
+	}
+
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, cf.ConstantPool[tt.index-1].String())
+	}
+
+	assert.Equal(t, int32(-42), cf.ConstantPool[tests[0].index-1].(*ConstantInteger).Int32())
+	assert.Equal(t, float32(3.14), cf.ConstantPool[tests[1].index-1].(*ConstantFloat).Float32())
+	assert.Equal(t, int64(-9223372036854775808), cf.ConstantPool[tests[7].index-1].(*ConstantLong).Int64())
+	assert.Equal(t, 0.1, cf.ConstantPool[tests[8].index-1].(*ConstantDouble).Float64())
+}
+
+func TestResolveConstants(t *testing.T) {
+	f, err := os.Open("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+
+	cf, err := Parse(f)
+	require.NoError(t, err)
+
+	methodref, ok := cf.ConstantPool[0].(*ConstantMethodref)
+	require.True(t, ok)
+	assert.Equal(t, "java/lang/Object", methodref.Owner())
+	assert.Equal(t, "<init>", methodref.Name())
+	assert.Equal(t, "()V", methodref.Descriptor())
+	assert.Equal(t, `java/lang/Object."<init>":()V`, methodref.Symbolic())
+
+	fieldref, ok := cf.ConstantPool[6].(*ConstantFieldref)
+	require.True(t, ok)
+	assert.Equal(t, "java/lang/System", fieldref.Class().Name())
+	assert.Equal(t, "out", fieldref.NameAndType().Name())
+	assert.Equal(t, "java/lang/System.out:Ljava/io/PrintStream;", fieldref.Symbolic())
+
+	str, ok := cf.ConstantPool[12].(*ConstantString)
+	require.True(t, ok)
+	assert.Equal(t, "Hello, world", str.Value())
+
+	c, ok := cf.ConstantAt(2)
+	require.True(t, ok)
+	assert.Equal(t, "java/lang/Object", c.Symbolic())
+	_, ok = cf.ConstantAt(0)
+	assert.False(t, ok)
+	_, ok = cf.ConstantAt(uint16(len(cf.ConstantPool) + 1))
+	assert.False(t, ok)
+
+	b := newClassBuilder("Handles", "java/lang/Object")
+	metafactory := b.methodref("java/lang/invoke/LambdaMetafactory", "metafactory", "()V")
+	handle := b.methodHandle(6, metafactory)
+	array := b.class("[Ljava/lang/String;")
+	long := b.long(1)
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	c, ok = cf.ConstantAt(handle)
+	require.True(t, ok)
+	mh := c.(*ConstantMethodHandle)
+	assert.Equal(t, RefInvokeStatic, mh.Kind())
+	assert.Equal(t, "REF_invokeStatic", mh.Kind().String())
+	assert.Equal(t, fmt.Sprintf("REF_invokeStatic #%d", metafactory), mh.String())
+	assert.IsType(t, &ConstantMethodref{}, mh.Reference())
+	assert.Equal(t, "REF_invokeStatic java/lang/invoke/LambdaMetafactory.metafactory:()V", mh.Symbolic())
+
+	c, ok = cf.ConstantAt(array)
+	require.True(t, ok)
+	assert.Equal(t, `"[Ljava/lang/String;"`, c.Symbolic())
+
+	_, ok = cf.ConstantAt(long + 1)
+	assert.False(t, ok)
+}
+
+func TestDescriptors(t *testing.T) {
+	f, err := os.Open("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+
+	cf, err := Parse(f)
+	require.NoError(t, err)
+
+	main := cf.Methods()[1]
+	mt, ok := main.Type()
+	require.True(t, ok)
+	assert.Equal(t, "void main(java.lang.String[])", mt.JavaDeclaration(main.Name()))
+
+	data, err := os.ReadFile("../testdata/NumberToJSON.class")
+	require.NoError(t, err)
+	cf, err = Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	types := map[string]string{}
+	for _, f := range cf.Fields() {
+		ft, ok := f.Type()
+		require.True(t, ok)
+		types[f.Name()] = ft.JavaName()
+	}
+	assert.Equal(t, "long", types["DOUBLE_MANTISSA_MASK"])
+	assert.Equal(t, "java.math.BigInteger[]", types["POW5"])
+	assert.Equal(t, "int[][]", types["POW5_SPLIT"])
+	for _, m := range cf.Methods() {
+		if m.Name() == "mulPow5divPow2" {
+			mt, ok := m.Type()
+			require.True(t, ok)
+			assert.Equal(t, "long mulPow5divPow2(long, int, int)", mt.JavaDeclaration(m.Name()))
+			// a long takes two slots
+			assert.Equal(t, 4, mt.ParamSlots())
+		}
+	}
+
+	// a static method can take 255 slots, though none of the compiled classes in testdata has such a method
+	b := newClassBuilder("Descriptors", "java/lang/Object")
+	b.method(0x0009, "many", "("+strings.Repeat("J", 127)+"I)V")
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	mt, ok = cf.Methods()[0].Type()
+	require.True(t, ok)
+	assert.Equal(t, 255, mt.ParamSlots())
+
+	for _, build := range []func(b *classBuilder){
+		func(b *classBuilder) { b.field(0x0001, "f", "java/lang/String") },
+		func(b *classBuilder) { b.method(0x0001, "m", "(I)") },
+		// an instance method needs one more slot for `this`
+		func(b *classBuilder) { b.method(0x0001, "m", "("+strings.Repeat("J", 127)+"I)V") },
+		func(b *classBuilder) {
+			b.attributes = append(b.attributes, b.attribute("RuntimeVisibleAnnotations", u2(1), u2(b.utf8("Deprecated")), u2(0)))
+		},
+	} {
+		b := newClassBuilder("Descriptors", "java/lang/Object")
+		build(b)
+		_, err := Parse(bytes.NewReader(b.build()))
+		assert.Error(t, err)
+	}
+}
+
+func TestSignatures(t *testing.T) {
+	b := newClassBuilder("Box", "java/lang/Object")
+	b.interfaces = append(b.interfaces, b.class("java/lang/Comparable"))
+	b.attributes = append(b.attributes, b.attribute("Signature", u2(b.utf8("<T:Ljava/lang/Number;>Ljava/lang/Object;Ljava/lang/Comparable<LBox<TT;>;>;"))))
+	b.field(0x0002, "items", "Ljava/util/List;", b.attribute("Signature", u2(b.utf8("Ljava/util/List<+TT;>;"))))
+	b.field(0x0002, "count", "I")
+	b.method(0x0001, "map", "(Ljava/util/function/Function;)LBox;",
+		b.attribute("Signature", u2(b.utf8("<R:Ljava/lang/Number;X:Ljava/lang/Exception;>(Ljava/util/function/Function<-TT;+TR;>;)LBox<TR;>;^TX;"))))
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	sig, ok := cf.Signature()
+	require.True(t, ok)
+	assert.Equal(t, "Box<T extends java.lang.Number> implements java.lang.Comparable<Box<T>>", sig.JavaDeclaration("Box", cf.AccessFlags&AccessFlagsInterface != 0))
+
+	fieldSig, ok := cf.Fields()[0].Signature()
+	require.True(t, ok)
+	assert.Equal(t, "java.util.List<? extends T>", fieldSig.JavaName())
+	_, ok = cf.Fields()[1].Signature()
+	assert.False(t, ok)
+
+	methodSig, ok := cf.Methods()[0].Signature()
+	require.True(t, ok)
+	assert.Equal(t, "<R extends java.lang.Number, X extends java.lang.Exception> Box<R> map(java.util.function.Function<? super T, ? extends R>) throws X", methodSig.JavaDeclaration("map"))
+
+	// a method signature is not allowed for a field, nor a field signature for a class
+	for _, build := range []func(b *classBuilder){
+		func(b *classBuilder) {
+			b.field(0x0002, "f", "I", b.attribute("Signature", u2(b.utf8("()V"))))
+		},
+		func(b *classBuilder) {
+			b.attributes = append(b.attributes, b.attribute("Signature", u2(b.utf8("TT;"))))
+		},
+		func(b *classBuilder) {
+			b.method(0x0001, "m", "()V", b.attribute("Signature", u2(b.utf8("Ljava/lang/Object;"))))
+		},
+	} {
+		b := newClassBuilder("Box", "java/lang/Object")
+		build(b)
+		_, err := Parse(bytes.NewReader(b.build()))
+		assert.Error(t, err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	b := newClassBuilder("pkg/Names", "java/lang/Object")
+	b.class("[[Ljava/lang/String;")
+	b.module(`my.module\@v1`)
+	b.pkg("pkg/sub")
+	b.field(0x0002, "$value", "I")
+	b.method(0x0001, "<init>", "()V")
+	b.method(0x0008, "<clinit>", "()V")
+	b.method(0x0001, "run", "()V")
+	_, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		build func(b *classBuilder)
+		want  string
+	}{
+		{"dotted class name", func(b *classBuilder) { b.class("java.lang.Object") }, `"java.lang.Object" contains an invalid identifier`},
+		{"empty identifier", func(b *classBuilder) { b.class("java//Object") }, "empty identifier"},
+		{"invalid array class", func(b *classBuilder) { b.class("[Q") }, "class name"},
+		{"unescaped module name", func(b *classBuilder) { b.module("my:module") }, "unescaped ':'"},
+		{"invalid escape in module name", func(b *classBuilder) { b.module(`my\module`) }, "invalid escape"},
+		{"package name", func(b *classBuilder) { b.pkg("pkg/") }, "empty identifier"},
+		{"field name", func(b *classBuilder) { b.field(0x0002, "a.b", "I") }, `"a.b" contains '.'`},
+		{"empty field name", func(b *classBuilder) { b.field(0x0002, "", "I") }, "empty name"},
+		{"method name", func(b *classBuilder) { b.method(0x0001, "<run>", "()V") }, `"<run>" contains '<'`},
+		{"constructor returning a value", func(b *classBuilder) { b.method(0x0001, "<init>", "()I") }, "<init>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newClassBuilder("pkg/Names", "java/lang/Object")
+			tt.build(b)
+			_, err := Parse(bytes.NewReader(b.build()))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConstantValue(t *testing.T) {
+	b := newClassBuilder("Constants", "java/lang/Object")
+	constant := func(index uint16) []byte { return b.attribute("ConstantValue", u2(index)) }
+	b.field(0x0019, "I", "I", constant(b.integer(-7)))
+	b.field(0x0019, "B", "B", constant(b.integer(0x1FF)))
+	b.field(0x0019, "C", "C", constant(b.integer(0x10041)))
+	b.field(0x0019, "S", "S", constant(b.integer(0x18000)))
+	b.field(0x0019, "Z", "Z", constant(b.integer(3)))
+	b.field(0x0019, "EVEN", "Z", constant(b.integer(2)))
+	b.field(0x0019, "F", "F", constant(b.float(1.5)))
+	b.field(0x0019, "J", "J", constant(b.long(1<<40)))
+	b.field(0x0019, "D", "D", constant(b.double(2.5)))
+	b.field(0x0019, "STR", "Ljava/lang/String;", constant(b.str("text")))
+	b.field(0x0002, "none", "I")
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	want := []any{int32(-7), int8(-1), uint16('A'), int16(-32768), true, false, float32(1.5), int64(1 << 40), 2.5, "text"}
+	for i, w := range want {
+		v, ok := cf.Fields()[i].ConstantValue()
+		assert.True(t, ok)
+		assert.Equal(t, w, v, cf.Fields()[i].Name())
+	}
+	_, ok := cf.Fields()[len(want)].ConstantValue()
+	assert.False(t, ok)
+
+	for desc, value := range map[string]func(b *classBuilder) uint16{
+		"J":                  func(b *classBuilder) uint16 { return b.integer(1) },
+		"I":                  func(b *classBuilder) uint16 { return b.long(1) },
+		"F":                  func(b *classBuilder) uint16 { return b.double(1) },
+		"Ljava/lang/String;": func(b *classBuilder) uint16 { return b.integer(1) },
+		"Ljava/lang/Object;": func(b *classBuilder) uint16 { return b.str("x") },
+	} {
+		b := newClassBuilder("Constants", "java/lang/Object")
+		b.field(0x0019, "C", desc, b.attribute("ConstantValue", u2(value(b))))
+		_, err := Parse(bytes.NewReader(b.build()))
+		assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+
+		// the mismatched constant is not returned in lenient mode
+		cf, diags, err := ParseWithOptions(bytes.NewReader(b.build()), Options{Lenient: true})
+		require.NoError(t, err)
+		assert.Len(t, diags, 1)
+		v, ok := cf.Fields()[0].ConstantValue()
+		assert.False(t, ok)
+		assert.Nil(t, v)
+
+		// the attribute of a non-static field is ignored, whatever the constant is
+		b = newClassBuilder("Constants", "java/lang/Object")
+		b.field(0x0011, "C", desc, b.attribute("ConstantValue", u2(value(b))))
+		cf, err = Parse(bytes.NewReader(b.build()))
+		require.NoError(t, err)
+		_, ok = cf.Fields()[0].ConstantValue()
+		assert.False(t, ok)
+	}
+}
+
+func TestAccessFlags(t *testing.T) {
+	assert.Equal(t, "public final", (AccessFlagsPublic | AccessFlagsSuper | AccessFlagsFinal).String())
+	assert.Equal(t, "public", (AccessFlagsPublic | AccessFlagsInterface | AccessFlagsAbstract).String())
+	assert.Equal(t, "public static final", (FieldAccessFlagsFinal | FieldAccessFlagsStatic | FieldAccessFlagsPublic | FieldAccessFlagsSynthetic).String())
+	assert.Equal(t, "private transient volatile", (FieldAccessFlagsPrivate | FieldAccessFlagsVolatile | FieldAccessFlagsTransient).String())
+	assert.Equal(t, "protected abstract", (MethodAccessFlagsProtected | MethodAccessFlagsAbstract | MethodAccessFlagsVarargs).String())
+	assert.Equal(t, "public static synchronized native", (MethodAccessFlagsPublic | MethodAccessFlagsStatic | MethodAccessFlagsSynchronized | MethodAccessFlagsNative).String())
+	assert.Equal(t, "private static", (InnerClassAccessFlagsPrivate | InnerClassAccessFlagsStatic | InnerClassAccessFlagsInterface | InnerClassAccessFlagsAbstract).String())
+	assert.Equal(t, "final", (ParameterAccessFlagsFinal | ParameterAccessFlagsMandated).String())
+	assert.Equal(t, "open", ModuleFlagsOpen.String())
+	assert.Equal(t, "transitive static", (RequiresFlagsTransitive | RequiresFlagsStaticPhase).String())
+	assert.Equal(t, "", ExportsFlagsMandated.String())
+
+	b := newClassBuilder("Flags", "java/lang/Object")
+	b.field(0x0019, "CONSTANT", "I")
+	b.method(0x0001, "<init>", "()V")
+	b.method(0x0008, "<clinit>", "()V")
+	b.method(0x0001, "run", "(II)V", b.attribute("MethodParameters", u1(2), u2(b.utf8("a")), u2(0x0010), u2(0), u2(0x1000)))
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	assert.Equal(t, AccessFlagsPublic|AccessFlagsSuper, cf.AccessFlags)
+	assert.Equal(t, "public static final", cf.Fields()[0].AccessFlags().String())
+	assert.Equal(t, []MethodParameter{
+		{Name: "a", AccessFlags: ParameterAccessFlagsFinal},
+		{AccessFlags: ParameterAccessFlagsSynthetic},
+	}, cf.Methods()[2].Parameters())
+	assert.Nil(t, cf.Methods()[0].Parameters())
+
+	tests := []struct {
+		name  string
+		build func(b *classBuilder)
+	}{
+		{"interface without abstract", func(b *classBuilder) { b.accessFlags = 0x0201 }},
+		{"final interface", func(b *classBuilder) { b.accessFlags = 0x0611 }},
+		{"annotation without interface", func(b *classBuilder) { b.accessFlags = 0x2001 }},
+		{"final abstract class", func(b *classBuilder) { b.accessFlags = 0x0431 }},
+		{"module with other flags", func(b *classBuilder) { b.accessFlags = 0x8001 }},
+		{"public private field", func(b *classBuilder) { b.field(0x0003, "f", "I") }},
+		{"final volatile field", func(b *classBuilder) { b.field(0x0050, "f", "I") }},
+		{"non-static interface field", func(b *classBuilder) {
+			b.accessFlags = 0x0601
+			b.field(0x0011, "f", "I")
+		}},
+		{"public protected method", func(b *classBuilder) { b.method(0x0005, "m", "()V") }},
+		{"abstract static method", func(b *classBuilder) { b.method(0x0408, "m", "()V") }},
+		{"final interface method", func(b *classBuilder) {
+			b.accessFlags = 0x0601
+			b.method(0x0411, "m", "()V")
+		}},
+		{"package-private interface method", func(b *classBuilder) {
+			b.accessFlags = 0x0601
+			b.method(0x0400, "m", "()V")
+		}},
+		{"static constructor", func(b *classBuilder) { b.method(0x0009, "<init>", "()V") }},
+		{"strict abstract method in version 60", func(b *classBuilder) {
+			b.major = 60
+			b.accessFlags = 0x0421
+			b.method(0x0C01, "m", "()V")
+		}},
+		{"static interface method before version 52", func(b *classBuilder) {
+			b.major = 51
+			b.accessFlags = 0x0601
+			b.method(0x0009, "m", "()V")
+		}},
+		{"strict interface method before version 52", func(b *classBuilder) {
+			b.major = 51
+			b.accessFlags = 0x0601
+			b.method(0x0C01, "m", "()V")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newClassBuilder("Flags", "java/lang/Object")
+			tt.build(b)
+			_, err := Parse(bytes.NewReader(b.build()))
+			assert.ErrorIs(t, err, ErrInvalidAccessFlags)
+
+			// the illegal flags are reported as a diagnostic in lenient mode
+			_, diags, err := ParseWithOptions(bytes.NewReader(b.build()), Options{Lenient: true})
+			require.NoError(t, err)
+			if assert.Len(t, diags, 1) {
+				assert.Equal(t, SeverityError, diags[0].Severity)
+				assert.ErrorIs(t, diags[0], ErrInvalidAccessFlags)
+			}
+		})
+	}
+
+	// the error of the method access_flags points at the flags, though they are checked after name_index is read
+	b = newClassBuilder("Flags", "java/lang/Object")
+	b.method(0x0001, "m", "()V")
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	b = newClassBuilder("Flags", "java/lang/Object")
+	b.method(0x0005, "m", "()V")
+	_, err = Parse(bytes.NewReader(b.build()))
+	var pe *ParseError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "methods[0].access_flags", pe.Path)
+		assert.Equal(t, cf.Methods()[0].Span().Offset, pe.Offset)
+		assert.Equal(t, "at most one of ACC_PUBLIC, ACC_PRIVATE and ACC_PROTECTED", pe.Expected)
+		assert.Equal(t, "0x0005", pe.Actual)
+	}
+
+	legal := []struct {
+		name  string
+		build func(b *classBuilder)
+	}{
+		{"interface members", func(b *classBuilder) {
+			b.accessFlags = 0x0601
+			b.field(0x0019, "F", "I")
+			b.method(0x0401, "m", "()V")
+			b.method(0x0002, "helper", "()V")
+		}},
+		// an interface method before version 52 may be a bridge or varargs method in addition
+		{"bridge varargs interface method before version 52", func(b *classBuilder) {
+			b.major = 51
+			b.accessFlags = 0x0601
+			b.method(0x14C1, "m", "([I)V")
+		}},
+		// ACC_STRICT is obsolete from version 61, so that it no longer conflicts with ACC_ABSTRACT
+		{"strict abstract method in version 61", func(b *classBuilder) {
+			b.major = 61
+			b.accessFlags = 0x0421
+			b.method(0x0C01, "m", "()V")
+		}},
+	}
+	for _, tt := range legal {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newClassBuilder("Flags", "java/lang/Object")
+			tt.build(b)
+			_, err := Parse(bytes.NewReader(b.build()))
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	data, err := os.ReadFile("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+
+	_, err = Parse(bytes.NewReader([]byte{0xCA, 0xFE, 0xBA, 0xBF}))
+	assert.ErrorIs(t, err, ErrBadMagic)
+	var pe *ParseError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "magic", pe.Path)
+		assert.Equal(t, int64(0), pe.Offset)
+		assert.Equal(t, "[202 254 186 190]", pe.Expected)
+		assert.Equal(t, "[202 254 186 191]", pe.Actual)
+	}
+
+	// the SourceFile attribute is cut in its attribute_length
+	_, err = Parse(bytes.NewReader(data[:len(data)-3]))
+	assert.ErrorIs(t, err, ErrTruncated)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "attributes[0].attribute_length", pe.Path)
+		assert.Equal(t, int64(len(data)-6), pe.Offset)
+	}
+
+	_, err = Parse(bytes.NewReader(append(append([]byte{}, data...), 0)))
+	assert.ErrorIs(t, err, ErrTrailingBytes)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "attributes", pe.Path)
+		assert.Equal(t, int64(len(data)), pe.Offset)
+	}
+
+	// the last constant_pool entry is a CONSTANT_Long_info, which takes up the entry at constant_pool_count
+	b := newClassBuilder("Errors", "java/lang/Object")
+	long := b.long(1)
+	wide := b.build()
+	wide[9]--
+	_, err = Parse(bytes.NewReader(wide))
+	assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, fmt.Sprintf("constant_pool[%d]", long), pe.Path)
+		// the entry is followed by the items from access_flags to attributes_count in 14 bytes
+		assert.Equal(t, int64(len(wide)-14-9), pe.Offset)
+		assert.Equal(t, byte(ConstantKindLong), wide[pe.Offset])
+	}
+
+	b = newClassBuilder("Errors", "java/lang/Object")
+	b.major = 44
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "major_version", pe.Path)
+		assert.Equal(t, int64(6), pe.Offset)
+		assert.Equal(t, ">= 45", pe.Expected)
+		assert.Equal(t, "44", pe.Actual)
+	}
+
+	b = newClassBuilder("Errors", "java/lang/Object")
+	b.method(0x0001, "run", "()V", b.returnCode(b.utf8("NotAClass")))
+	data = b.build()
+	_, err = Parse(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "methods[0].attributes[0].exception_table[0].catch_type", pe.Path)
+		// the catch_type item is followed by attributes_count of Code and of the class
+		assert.Equal(t, int64(len(data)-6), pe.Offset)
+		assert.Contains(t, pe.Error(), "fail to parse methods[0].attributes[0].exception_table[0].catch_type at offset")
+	}
+}
+
+func TestSpans(t *testing.T) {
+	data, err := os.ReadFile("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+
+	cf, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	// the first entry starts just after magic, versions and constant_pool_count
+	first := cf.ConstantPool[0].Span()
+	assert.Equal(t, Span{Offset: 10, Length: 5}, first)
+	assert.Equal(t, byte(ConstantKindMethodref), data[first.Offset])
+	for i := 1; i < len(cf.ConstantPool); i++ {
+		assert.Equal(t, cf.ConstantPool[i-1].Span().End(), cf.ConstantPool[i].Span().Offset)
+	}
+	utf8 := cf.ConstantPool[3].Span()
+	assert.Equal(t, "java/lang/Object", string(data[utf8.Offset+3:utf8.End()]))
+
+	methods := cf.Methods()
+	require.Len(t, methods, 2)
+	assert.Equal(t, methods[0].Span().End(), methods[1].Span().Offset)
+	code := methods[1].Code()
+	attr := methods[1].Attributes()[0]
+	assert.Equal(t, "Code", attr.Name())
+	assert.Equal(t, methods[1].Span().Offset+8, attr.Span().Offset)
+	assert.Equal(t, methods[1].Span().End(), attr.Span().End())
+	assert.Equal(t, code.Bytecode(), data[code.BytecodeOffset():code.BytecodeOffset()+int64(len(code.Bytecode()))])
+
+	attrs := cf.Attributes()
+	assert.Equal(t, int64(len(data)), attrs[len(attrs)-1].Span().End())
+
+	data, err = os.ReadFile("../testdata/Sign.class")
+	require.NoError(t, err)
+	cf, err = Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	fields := cf.Fields()
+	for i := 1; i < len(fields); i++ {
+		assert.Equal(t, fields[i-1].Span().End(), fields[i].Span().Offset)
+	}
+	// methods_count is between the last field and the first method
+	assert.Equal(t, fields[len(fields)-1].Span().End()+2, cf.Methods()[0].Span().Offset)
+	attr = fields[0].Attributes()[0]
+	assert.Equal(t, "RuntimeInvisibleAnnotations", attr.Name())
+	assert.Equal(t, fields[0].Span().Offset+8, attr.Span().Offset)
+	assert.Equal(t, fields[0].Span().End(), attr.Span().End())
+	// the annotation follows the attribute header and num_annotations, and fills the rest of the attribute
+	an := fields[0].Annotations()[0]
+	assert.Equal(t, attr.Span().Offset+6+2, an.Span.Offset)
+	assert.Equal(t, attr.Span().End(), an.Span.End())
+	assert.Equal(t, []byte{0, 1}, data[an.Span.Offset-2:an.Span.Offset])
+
+	// none of the compiled classes in testdata has type annotations
+	b := newClassBuilder("Spans", "java/lang/Object")
+	b.field(0x0002, "a", "I")
+	b.field(0x0002, "b", "I", b.attribute("RuntimeVisibleTypeAnnotations", u2(1), u1(0x13), u1(0), u2(b.utf8("LA;")), u2(0)))
+	cf, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	fields = cf.Fields()
+	assert.Equal(t, int64(8), fields[0].Span().Length)
+	ta := fields[1].TypeAnnotations()[0]
+	assert.Equal(t, fields[1].Span().Offset+8+8, ta.Span.Offset)
+	assert.Equal(t, int64(6), ta.Span.Length)
+	assert.Equal(t, "[0x0, 0x4)", Span{Length: 4}.String())
+}
+
+// fixtures reads the compiled classes in testdata by their file names.
+func fixtures(tb testing.TB) map[string][]byte {
+	paths, err := filepath.Glob("../testdata/*.class")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	inputs := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		inputs[filepath.Base(path)] = data
+	}
+	return inputs
+}
+
+func TestLenient(t *testing.T) {
+	// the compiled classes have nothing to report
+	for file, data := range fixtures(t) {
+		_, diags, err := ParseWithOptions(bytes.NewReader(data), Options{Lenient: true})
+		require.NoError(t, err, file)
+		assert.Empty(t, diags, file)
+	}
+
+	b := newClassBuilder("Damaged", "java/lang/Object")
+	b.field(0x0019, "COUNT", "I", b.attribute("ConstantValue", u2(b.str("ten"))))
+	b.method(0x0001, "run", "()V", b.returnCode(b.utf8("NotAClass")))
+	b.method(0x0001, "stop", "()V", b.returnCode())
+	b.attributes = append(b.attributes, b.attribute("NestMembers", u2(1), u2(b.class("Damaged$Inner")), u1(0)))
+	data := b.build()
+
+	_, err := Parse(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+	var pe *ParseError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "fields[0].ConstantValue_attribute's constantvalue_index", pe.Path)
+	}
+
+	cf, diags, err := ParseWithOptions(bytes.NewReader(data), Options{Lenient: true})
+	require.NoError(t, err)
+	if assert.Len(t, diags, 3) {
+		assert.Equal(t, SeverityError, diags[0].Severity)
+		assert.Equal(t, "fields[0].ConstantValue_attribute's constantvalue_index", diags[0].Path)
+		assert.Equal(t, pe.Offset, diags[0].Offset)
+		assert.ErrorIs(t, diags[0], ErrBadConstantPoolReference)
+		assert.Equal(t, SeverityError, diags[1].Severity)
+		assert.Equal(t, "methods[0].attributes[0].exception_table[0].catch_type", diags[1].Path)
+		assert.ErrorIs(t, diags[1], ErrBadConstantPoolReference)
+		assert.Equal(t, SeverityWarning, diags[2].Severity)
+		assert.Equal(t, "attributes[0].info", diags[2].Path)
+		assert.ErrorIs(t, diags[2], ErrTrailingBytes)
+		assert.Equal(t, "error: "+diags[1].Error(), diags[1].String())
+	}
+
+	// the constantvalue_index follows the attribute_name_index and attribute_length
+	assert.Equal(t, cf.Fields()[0].Attributes()[0].Span().Offset+6, pe.Offset)
+
+	// the invalid attribute is skipped by its attribute_length and kept as it is
+	run := cf.Methods()[0]
+	assert.Nil(t, run.Code())
+	if attr, ok := run.Attributes()[0].(*RawAttribute); assert.True(t, ok) {
+		assert.Equal(t, "Code", attr.Name())
+		assert.Len(t, attr.Info(), 21)
+	}
+	assert.NotNil(t, cf.Methods()[1].Code())
+	assert.Equal(t, []string{"Damaged$Inner"}, cf.NestMembers())
+
+	// the parser cannot resume after a truncated structure
+	cf, diags, err = ParseWithOptions(bytes.NewReader(data[:len(data)-4]), Options{Lenient: true})
+	assert.ErrorIs(t, err, ErrTruncated)
+	if assert.Len(t, diags, 3) {
+		assert.Equal(t, SeverityFatal, diags[2].Severity)
+		assert.ErrorIs(t, diags[2], ErrTruncated)
+		// the 5 bytes of the NestMembers info are cut to 1 byte
+		assert.Equal(t, "attributes[0].info", diags[2].Path)
+		assert.Equal(t, int64(len(data)-5), diags[2].Offset)
+	}
+	assert.Equal(t, "Damaged", cf.ThisClassName())
+	assert.Len(t, cf.Methods(), 2)
+	assert.Empty(t, cf.Attributes())
+
+	// on the zero-copy path, the attributes decoded lazily are decoded while parsing in lenient mode
+	_, diags, err = ParseWithOptions(bytes.NewReader(data), Options{Lenient: true})
+	require.NoError(t, err)
+	_, lazy, err := ParseBytesWithOptions(data, Options{Lenient: true})
+	require.NoError(t, err)
+	assert.Equal(t, diags, lazy)
+}
+
+func TestVersions(t *testing.T) {
+	data, err := os.ReadFile("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+
+	cf, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "Java 18", cf.JavaRelease())
+	assert.False(t, cf.IsPreview())
+
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{MaxMajorVersion: 61})
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	var pe *ParseError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "major_version", pe.Path)
+		assert.Equal(t, int64(6), pe.Offset)
+	}
+
+	for _, v := range []struct {
+		major, minor uint16
+		release      string
+	}{
+		{45, 3, "Java 1.0.2"},
+		{45, 4, "Java 1.1"},
+		{48, 0, "Java 1.4"},
+		{49, 0, "Java 5"},
+		{52, 0, "Java 8"},
+	} {
+		cf := ClassFile{MajorVer: v.major, MinorVer: v.minor}
+		assert.Equal(t, v.release, cf.JavaRelease())
+	}
+
+	// the class file of Java 18 with preview features
+	preview := append([]byte{}, data...)
+	preview[4], preview[5] = 0xFF, 0xFF
+	cf, err = Parse(bytes.NewReader(preview))
+	require.NoError(t, err)
+	assert.True(t, cf.IsPreview())
+
+	preview[4], preview[5] = 0, 1
+	_, err = Parse(bytes.NewReader(preview))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "minor_version", pe.Path)
+		assert.Equal(t, int64(4), pe.Offset)
+	}
+
+	// CONSTANT_Module_info is allowed only from 53
+	b := newClassBuilder("module-info", "")
+	b.major = 52
+	module := b.module("java.base")
+	data = b.build()
+	_, err = Parse(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, fmt.Sprintf("constant_pool[%d].cp_info tag", module), pe.Path)
+		assert.Equal(t, byte(ConstantKindModule), data[pe.Offset])
+	}
+
+	// NestMembers is not predefined before 55, so that it is kept as an unrecognized attribute
+	data, err = os.ReadFile("../testdata/Sign.class")
+	require.NoError(t, err)
+	cf, err = Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, "Java 11", cf.JavaRelease())
+	assert.Equal(t, []string{"sigstore/plugin/Sign$1"}, cf.NestMembers())
+
+	data = append([]byte{}, data...)
+	data[7] = 54
+	cf, err = Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Empty(t, cf.NestMembers())
+	if attr, ok := cf.Attributes()[2].(*RawAttribute); assert.True(t, ok) {
+		assert.Equal(t, "NestMembers", attr.Name())
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	parsers := map[string]func(data []byte) (*ClassFile, error){
+		"Bytes": ParseBytes,
+		"ReaderAt": func(data []byte) (*ClassFile, error) {
+			return ParseReaderAt(bytes.NewReader(data), int64(len(data)))
+		},
+	}
+	for file, data := range fixtures(t) {
+		want, err := Parse(bytes.NewReader(data))
+		require.NoError(t, err)
+		last := want.Attributes()[len(want.Attributes())-1].Span().Offset
+		path := fmt.Sprintf("attributes[%d].attribute_length", len(want.Attributes())-1)
+
+		for name, parse := range parsers {
+			t.Run(file+"/"+name, func(t *testing.T) {
+				cf, err := parse(data)
+				require.NoError(t, err)
+				assert.Equal(t, want.ThisClassName(), cf.ThisClassName())
+				assert.Equal(t, want.SourceFile(), cf.SourceFile())
+				assert.Equal(t, want.Attributes(), cf.Attributes())
+				if assert.Len(t, cf.Fields(), len(want.Fields())) {
+					for i, f := range cf.Fields() {
+						assert.Equal(t, want.Fields()[i].Annotations(), f.Annotations())
+					}
+				}
+				if assert.Len(t, cf.Methods(), len(want.Methods())) {
+					for i, m := range cf.Methods() {
+						w := want.Methods()[i]
+						assert.Equal(t, w.Name(), m.Name())
+						assert.Equal(t, w.Attributes(), m.Attributes())
+						if w.Code() == nil {
+							assert.Nil(t, m.Code())
+							continue
+						}
+						assert.Equal(t, w.Code().Bytecode(), m.Code().Bytecode())
+						assert.Equal(t, w.Code().BytecodeOffset(), m.Code().BytecodeOffset())
+						assert.Equal(t, w.Code().LineNumberTable(), m.Code().LineNumberTable())
+					}
+				}
+				assert.NoError(t, cf.DecodeAll())
+
+				// the input ends in the attribute_length of the last attribute
+				_, err = parse(data[:last+4])
+				assert.ErrorIs(t, err, ErrTruncated)
+				var pe *ParseError
+				if assert.ErrorAs(t, err, &pe) {
+					assert.Equal(t, path, pe.Path)
+					assert.Equal(t, last+2, pe.Offset)
+				}
+			})
+		}
+
+		// ParseReaderAt reads only the size bytes from the reader
+		padded := bytes.NewReader(append(append([]byte{}, data...), 0xCA, 0xFE))
+		cf, err := ParseReaderAt(padded, int64(len(data)))
+		require.NoError(t, err)
+		assert.NoError(t, cf.DecodeAll())
+		_, err = ParseReaderAt(padded, last+4)
+		assert.ErrorIs(t, err, ErrTruncated)
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe) {
+			// the truncation is found at the last attribute, as if the bytes after the size do not exist
+			assert.Equal(t, path, pe.Path)
+			assert.Equal(t, last+2, pe.Offset)
+		}
+	}
+
+	// the invalid Code attribute is found only when it is decoded
+	for name, parse := range parsers {
+		b := newClassBuilder("Lazy", "java/lang/Object")
+		b.method(0x0001, "run", "()V", b.returnCode(b.utf8("NotAClass")))
+		cf, err := parse(b.build())
+		require.NoError(t, err, name)
+		assert.Nil(t, cf.Methods()[0].Code())
+		if attr, ok := cf.Methods()[0].Attributes()[0].(*RawAttribute); assert.True(t, ok) {
+			assert.Equal(t, "Code", attr.Name())
+		}
+		// the method tells the Code attribute which fails to decode from no Code attribute
+		err = cf.Methods()[0].Decode()
+		assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+		var pe *ParseError
+		if assert.ErrorAs(t, err, &pe) {
+			assert.Equal(t, "methods[0].attributes[0].exception_table[0].catch_type", pe.Path)
+			// the catch_type item is followed by attributes_count of Code and of the class
+			assert.Equal(t, int64(len(b.build())-6), pe.Offset)
+		}
+		assert.Equal(t, err, cf.DecodeAll())
+	}
+}
+
+// readRecorder records the ranges read from the underlying reader.
+type readRecorder struct {
+	r     *bytes.Reader
+	reads []Span
+}
+
+func (r *readRecorder) ReadAt(p []byte, off int64) (int, error) {
+	r.reads = append(r.reads, Span{Offset: off, Length: int64(len(p))})
+	return r.r.ReadAt(p, off)
+}
+
+func TestLazyAnnotations(t *testing.T) {
+	data, err := os.ReadFile("../testdata/Sign.class")
+	require.NoError(t, err)
+	want, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	var codes []Span
+	for _, m := range want.Methods() {
+		for _, a := range m.Attributes() {
+			if a.Name() == "Code" {
+				codes = append(codes, a.Span())
+			}
+		}
+	}
+	require.NotEmpty(t, codes)
+
+	readAnnotations := func(cf *ClassFile) {
+		assert.Equal(t, want.Annotations(), cf.Annotations())
+		assert.True(t, cf.HasAnnotation("Lorg/apache/maven/plugins/annotations/Mojo;"))
+		for i, f := range cf.Fields() {
+			assert.Equal(t, want.Fields()[i].Annotations(), f.Annotations())
+			assert.Equal(t, want.Fields()[i].TypeAnnotations(), f.TypeAnnotations())
+		}
+		for _, m := range cf.Methods() {
+			assert.Empty(t, m.Annotations())
+			assert.Empty(t, m.ParameterAnnotations())
+			assert.Empty(t, m.TypeAnnotations())
+			assert.False(t, m.HasAnnotation("Ljava/lang/Deprecated;"))
+		}
+	}
+
+	t.Run("Bytes", func(t *testing.T) {
+		data := append([]byte{}, data...)
+		cf, err := ParseBytes(data)
+		require.NoError(t, err)
+		readAnnotations(cf)
+		// the Code attributes read after the annotations see the broken bytes if they have not been decoded yet
+		for _, s := range codes {
+			for i := s.Offset + 6; i < s.End(); i++ {
+				data[i] = 0xFF
+			}
+		}
+		for _, m := range cf.Methods() {
+			assert.Nil(t, m.Code())
+		}
+	})
+
+	t.Run("ReaderAt", func(t *testing.T) {
+		r := &readRecorder{r: bytes.NewReader(data)}
+		cf, err := ParseReaderAt(r, int64(len(data)))
+		require.NoError(t, err)
+		r.reads = nil
+		readAnnotations(cf)
+		for _, read := range r.reads {
+			for _, s := range codes {
+				assert.False(t, read.Offset < s.End() && s.Offset < read.End(), "read %v overlaps Code %v", read, s)
+			}
+		}
+	})
+}
+
+func TestLimits(t *testing.T) {
+	data, err := os.ReadFile("../testdata/HelloWorld.class")
+	require.NoError(t, err)
+
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{Limits: Limits{MaxBytes: int64(len(data))}})
+	assert.NoError(t, err)
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{Limits: Limits{MaxBytes: 100}})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	var pe *ParseError
+	if assert.ErrorAs(t, err, &pe) {
+		// the bytes of the entry from offset 98 go beyond the 100 bytes
+		assert.Equal(t, "constant_pool[12].CONSTANT_Utf8_info's bytes", pe.Path)
+		assert.Equal(t, int64(98), pe.Offset)
+	}
+
+	// the size of the input is checked in advance on the zero-copy path
+	_, _, err = ParseBytesWithOptions(data, Options{Limits: Limits{MaxBytes: int64(len(data))}})
+	assert.NoError(t, err)
+	_, _, err = ParseBytesWithOptions(data, Options{Limits: Limits{MaxBytes: 100}})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "ClassFile", pe.Path)
+		assert.Equal(t, int64(0), pe.Offset)
+	}
+	_, _, err = ParseReaderAtWithOptions(bytes.NewReader(data), int64(len(data)), Options{Limits: Limits{MaxBytes: 100}})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "ClassFile", pe.Path)
+		assert.Equal(t, int64(0), pe.Offset)
+	}
+
+	// the attributes which do not match their attribute_length, and the errors at the offsets from the end
+	for _, tt := range []struct {
+		name    string
+		build   func(b *classBuilder)
+		err     error
+		path    string
+		fromEnd int64
+	}{
+		// the attribute ends before the attributes_count of the class
+		{"read beyond attribute_length", func(b *classBuilder) {
+			b.method(0x0001, "run", "()V", b.attribute("Exceptions", u2(2), u2(b.class("java/io/IOException"))))
+		}, ErrAttributeOverrun, "methods[0].attributes[0].exception_index_table[1].exception_index_table", 2},
+		{"unread attribute_length", func(b *classBuilder) {
+			b.attributes = append(b.attributes, b.attribute("NestMembers", u2(1), u2(b.class("Bounds$Inner")), u1(0)))
+		}, ErrTrailingBytes, "attributes[0].info", 1},
+		// a count which cannot fit in the attribute fails before allocating the table,
+		// at the 6 bytes of the info followed by the attributes_count of the class
+		{"count beyond attribute_length", func(b *classBuilder) {
+			b.method(0x0001, "run", "()V", b.attribute("RuntimeVisibleAnnotations", u2(0xFFFF), u2(b.utf8("LA;")), u2(0)))
+		}, ErrAttributeOverrun, "methods[0].attributes[0].num_annotations", 2 + 6},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newClassBuilder("Bounds", "java/lang/Object")
+			tt.build(b)
+			data := b.build()
+			_, err := Parse(bytes.NewReader(data))
+			assert.ErrorIs(t, err, tt.err)
+			var pe *ParseError
+			if assert.ErrorAs(t, err, &pe) {
+				assert.Equal(t, tt.path, pe.Path)
+				assert.Equal(t, int64(len(data))-tt.fromEnd, pe.Offset)
+			}
+		})
+	}
+
+	data, err = os.ReadFile("../testdata/NumberToJSON.class")
+	require.NoError(t, err)
+	// the constant_pool table has 405 entries, more than any other table of the class
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{Limits: Limits{MaxCount: 405}})
+	assert.NoError(t, err)
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{Limits: Limits{MaxCount: 404}})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "constant_pool_count", pe.Path)
+		assert.Equal(t, int64(8), pe.Offset)
+	}
+
+	// element_value nests through arrays, which the Java language does not allow in annotations
+	b := newClassBuilder("Bounds", "java/lang/Object")
+	nested := concat(u1('I'), u2(b.integer(1)))
+	for i := 0; i < 4; i++ {
+		nested = concat(u1('['), u2(1), nested)
+	}
+	b.method(0x0001, "value", "()[[[[I", b.attribute("AnnotationDefault", nested))
+	data = b.build()
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{Limits: Limits{MaxDepth: 5}})
+	assert.NoError(t, err)
+	_, _, err = ParseWithOptions(bytes.NewReader(data), Options{Limits: Limits{MaxDepth: 4}})
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "methods[0].attributes[0].array_value.values[0].array_value.values[0].array_value.values[0].array_value.values[0].element_value", pe.Path)
+		// the innermost element_value is the last 3 bytes of the attribute
+		assert.Equal(t, int64(len(data)-2-3), pe.Offset)
+	}
+
+	// the limits also apply to the attributes decoded lazily
+	cf, _, err := ParseBytesWithOptions(data, Options{Limits: Limits{MaxDepth: 4}})
+	require.NoError(t, err)
+	err = cf.DecodeAll()
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, "methods[0].attributes[0].array_value.values[0].array_value.values[0].array_value.values[0].array_value.values[0].element_value", pe.Path)
+		// the innermost element_value is the last 3 bytes of the attribute
+		assert.Equal(t, int64(len(data)-2-3), pe.Offset)
+	}
+}
+
+func TestConstantPoolReferences(t *testing.T) {
+	bootstrap := func(b *classBuilder) []byte {
+		ref := b.methodref("pkg/Refs", "bootstrap", "(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;")
+		return b.attribute("BootstrapMethods", u2(1), u2(b.methodHandle(6, ref)), u2(0))
+	}
+
+	// the string concatenations compiled by javac 11 are invokedynamic call sites bootstrapped by StringConcatFactory
+	data, err := os.ReadFile("../testdata/Sign.class")
+	require.NoError(t, err)
+	cf, err := Parse(bytes.NewReader(data))
+	require.NoError(t, err)
+	if c, ok := cf.ConstantPool[266-1].(*ConstantInvokeDynamic); assert.True(t, ok) {
+		assert.Equal(t, uint16(0), c.BootstrapMethodAttrIndex())
+		assert.Regexp(t, `^#0:#\d+$`, c.String())
+		assert.Equal(t, "makeConcatWithConstants", c.Name())
+		assert.Equal(t, "(Ljava/net/URL;)Ljava/lang/String;", c.Descriptor())
+	}
+	if c, ok := cf.ConstantPool[677-1].(*ConstantMethodHandle); assert.True(t, ok) {
+		assert.Equal(t, RefInvokeStatic, c.Kind())
+		if ref, ok := c.Reference().(*ConstantMethodref); assert.True(t, ok) {
+			assert.Equal(t, "java/lang/invoke/StringConcatFactory", ref.Owner())
+			assert.Equal(t, "makeConcatWithConstants", ref.Name())
+		}
+	}
+
+	// none of the compiled classes in testdata has the other kinds of method handles, method types nor dynamic constants
+	b := newClassBuilder("pkg/Refs", "java/lang/Object")
+	b.methodHandle(9, b.entry(1, u1(11), u2(b.class("java/lang/Runnable")), u2(b.nameAndType("run", "()V"))))
+	b.methodHandle(8, b.methodref("pkg/Refs", "<init>", "()V"))
+	b.entry(1, u1(16), u2(b.utf8("()V")))
+	b.entry(1, u1(17), u2(0), u2(b.nameAndType("value", "I")))
+	b.entry(1, u1(18), u2(0), u2(b.nameAndType("run", "()Ljava/lang/Runnable;")))
+	// a field can be named <init>, unlike a method other than an instance initialization method
+	b.fieldref("pkg/Other", "<init>", "I")
+	b.entry(1, u1(17), u2(0), u2(b.nameAndType("<init>", "J")))
+	b.attributes = append(b.attributes, bootstrap(b))
+	_, err = Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+
+	b = newClassBuilder("pkg/Refs", "java/lang/Object")
+	path := func(i uint16, name string) string { return fmt.Sprintf("constant_pool[%d].%s", i, name) }
+	// the paths of the items, and their entries with the offsets in them
+	var want []string
+	var items []struct {
+		index  uint16
+		offset int64
+	}
+	expect := func(i uint16, name string, offset int64) {
+		want = append(want, path(i, name))
+		items = append(items, struct {
+			index  uint16
+			offset int64
+		}{i, offset})
+	}
+	// a Methodref whose class_index refers to a CONSTANT_Utf8_info structure
+	i := b.entry(1, u1(10), u2(b.utf8("pkg/Other")), u2(b.nameAndType("run", "()V")))
+	expect(i, "class_index", 1)
+	// a NameAndType whose name_index refers to a CONSTANT_Integer_info structure
+	i = b.entry(1, u1(12), u2(b.integer(1)), u2(b.utf8("I")))
+	expect(i, "name_index", 1)
+	// a Fieldref with a method descriptor
+	i = b.fieldref("pkg/Refs", "value", "()I")
+	expect(i, "name_and_type_index", 3)
+	// a Methodref to <clinit>, and <init> returning a value
+	i = b.methodref("pkg/Refs", "<clinit>", "()V")
+	expect(i, "name_and_type_index", 3)
+	i = b.methodref("pkg/Refs", "<init>", "()I")
+	expect(i, "name_and_type_index", 3)
+	// REF_getField referring to a method
+	i = b.methodHandle(1, b.methodref("pkg/Refs", "run", "()V"))
+	expect(i, "reference_index", 2)
+	// REF_invokeVirtual referring to <init>, and REF_newInvokeSpecial referring to another method
+	i = b.methodHandle(5, b.methodref("pkg/Refs", "<init>", "()V"))
+	expect(i, "reference_index", 2)
+	i = b.methodHandle(8, b.methodref("pkg/Refs", "run", "()V"))
+	expect(i, "reference_index", 2)
+	// an unknown reference_kind
+	i = b.methodHandle(10, b.methodref("pkg/Refs", "run", "()V"))
+	expect(i, "reference_kind", 1)
+	// a MethodType with a field descriptor
+	i = b.entry(1, u1(16), u2(b.utf8("I")))
+	expect(i, "descriptor_index", 1)
+	// an InvokeDynamic without the BootstrapMethods attribute, and a Dynamic with a method descriptor
+	i = b.entry(1, u1(18), u2(0), u2(b.nameAndType("run", "()Ljava/lang/Runnable;")))
+	expect(i, "bootstrap_method_attr_index", 1)
+	i = b.entry(1, u1(17), u2(0), u2(b.nameAndType("value", "()I")))
+	expect(i, "bootstrap_method_attr_index", 1)
+	expect(i, "name_and_type_index", 3)
+	data = b.build()
+
+	cf, diags, err := ParseWithOptions(bytes.NewReader(data), Options{Lenient: true})
+	require.NoError(t, err)
+	assert.Len(t, diags, len(want))
+
+	_, err = Parse(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+	var errs ParseErrors
+	if assert.ErrorAs(t, err, &errs) {
+		var paths []string
+		for k, pe := range errs {
+			paths = append(paths, pe.Path)
+			if k < len(items) {
+				assert.Equal(t, cf.ConstantPool[items[k].index-1].Span().Offset+items[k].offset, pe.Offset, pe.Path)
+			}
+		}
+		assert.Equal(t, want, paths)
+	}
+
+	// the accessors of a ClassFile parsed in lenient mode do not follow the invalid references
+	b = newClassBuilder("pkg/Refs", "java/lang/Object")
+	b.thisClass = b.entry(1, u1(7), u2(b.integer(1)))
+	b.interfaces = []uint16{b.class("java/lang/Runnable"), b.entry(1, u1(7), u2(b.integer(2)))}
+	b.attributes = append(b.attributes,
+		b.attribute("NestHost", u2(b.entry(1, u1(7), u2(b.integer(3))))),
+		b.attribute("EnclosingMethod", u2(b.class("pkg/Outer")), u2(b.entry(1, u1(12), u2(b.integer(4)), u2(b.utf8("()V"))))),
+	)
+	cf, diags, err = ParseWithOptions(bytes.NewReader(b.build()), Options{Lenient: true})
+	require.NoError(t, err)
+	assert.Len(t, diags, 4)
+	assert.Equal(t, "", cf.ThisClassName())
+	assert.Equal(t, "java/lang/Object", cf.SuperClassName())
+	assert.Equal(t, []string{"java/lang/Runnable", ""}, cf.InterfaceNames())
+	assert.Equal(t, "", cf.NestHost())
+	m, ok := cf.EnclosingMethod()
+	assert.True(t, ok)
+	assert.Equal(t, EnclosingMethod{ClassName: "pkg/Outer", MethodDescriptor: "()V"}, m)
+
+	// the bootstrap_method_attr_index must be in the bootstrap_methods array
+	b = newClassBuilder("pkg/Refs", "java/lang/Object")
+	i = b.entry(1, u1(18), u2(1), u2(b.nameAndType("run", "()Ljava/lang/Runnable;")))
+	b.attributes = append(b.attributes, bootstrap(b))
+	data = b.build()
+	cf, _, err = ParseWithOptions(bytes.NewReader(data), Options{Lenient: true})
+	require.NoError(t, err)
+	_, err = Parse(bytes.NewReader(data))
+	assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+	var pe *ParseError
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, path(i, "bootstrap_method_attr_index"), pe.Path)
+		assert.Equal(t, cf.ConstantPool[i-1].Span().Offset+1, pe.Offset)
+		assert.Equal(t, "index in 0..0", pe.Expected)
+	}
+
+	// REF_invokeStatic can refer to an interface method since version 52
+	b = newClassBuilder("pkg/Refs", "java/lang/Object")
+	i = b.methodHandle(6, b.entry(1, u1(11), u2(b.class("java/util/List")), u2(b.nameAndType("of", "()Ljava/util/List;"))))
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.NoError(t, err)
+	b.major = 51
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.ErrorIs(t, err, ErrBadConstantPoolReference)
+	if assert.ErrorAs(t, err, &pe) {
+		assert.Equal(t, path(i, "reference_index"), pe.Path)
+	}
 }
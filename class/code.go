@@ -0,0 +1,336 @@
+package class
+
+import "sort"
+
+type exceptionTableEntry struct {
+	startPC   uint16
+	endPC     uint16
+	handlerPC uint16
+	catchType uint16
+}
+
+type attributeCode struct {
+	attributeInfoBase
+	maxStack             uint16
+	maxLocals            uint16
+	codeLength           uint32
+	code                 []byte
+	codeOffset           int64
+	exceptionTableLength uint16
+	exceptionTable       []exceptionTableEntry
+	attributesCount      uint16
+	attributes           []attributeInfo
+}
+
+func (base *attributeInfoBase) code(er *errReader, cf *ClassFile) *attributeCode {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.3
+	attr := attributeCode{attributeInfoBase: *base}
+	item(er, "max_stack", integer(&attr.maxStack))
+	item(er, "max_locals", integer(&attr.maxLocals))
+	if item(er, "code_length", integer(&attr.codeLength, min[uint32](1), max[uint32](65535))) {
+		if item(er, "code", slice(&attr.code, int(attr.codeLength))) {
+			attr.codeOffset = er.offset
+		}
+	}
+
+	if item(er, "exception_table_length", integer(&attr.exceptionTableLength)) {
+		attr.exceptionTable = makeTable[exceptionTableEntry](er, cf, int(attr.exceptionTableLength))
+		item(er, "exception_table", entries(attr.exceptionTable, func(er *errReader) exceptionTableEntry {
+			var e exceptionTableEntry
+			item(er, "start_pc", integer(&e.startPC, max(uint16(attr.codeLength-1))))
+			item(er, "end_pc", integer(&e.endPC, min(e.startPC+1), max(uint16(attr.codeLength))))
+			item(er, "handler_pc", integer(&e.handlerPC, max(uint16(attr.codeLength-1))))
+			if item(er, "catch_type", integer(&e.catchType)) {
+				// zero if the exception handler is called for all exceptions
+				if e.catchType != 0 {
+					validate(er, e.catchType, constantPoolStructure[uint16, *ConstantClass](cf))
+				}
+			}
+			return e
+		}))
+	}
+
+	if item(er, "attributes_count", integer(&attr.attributesCount)) {
+		attr.attributes = makeTable[attributeInfo](er, cf, int(attr.attributesCount))
+		item(er, "attributes", entries(attr.attributes, func(er *errReader) attributeInfo {
+			return parseCodeAttributeInfo(er, cf)
+		}))
+	}
+	return &attr
+}
+
+type lineNumber struct {
+	startPC    uint16
+	lineNumber uint16
+}
+
+type attributeLineNumberTable struct {
+	attributeInfoBase
+	lineNumberTableLength uint16
+	lineNumberTable       []lineNumber
+}
+
+func (base *attributeInfoBase) lineNumberTable(er *errReader, cf *ClassFile) *attributeLineNumberTable {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.12
+	attr := attributeLineNumberTable{attributeInfoBase: *base}
+	if item(er, "line_number_table_length", integer(&attr.lineNumberTableLength)) {
+		attr.lineNumberTable = makeTable[lineNumber](er, cf, int(attr.lineNumberTableLength))
+		item(er, "line_number_table", entries(attr.lineNumberTable, func(er *errReader) lineNumber {
+			var l lineNumber
+			item(er, "start_pc", integer(&l.startPC))
+			item(er, "line_number", integer(&l.lineNumber))
+			return l
+		}))
+	}
+	return &attr
+}
+
+type localVariable struct {
+	startPC         uint16
+	length          uint16
+	nameIndex       uint16
+	descriptorIndex uint16
+	index           uint16
+}
+
+type attributeLocalVariableTable struct {
+	attributeInfoBase
+	localVariableTableLength uint16
+	localVariableTable       []localVariable
+}
+
+func (base *attributeInfoBase) localVariableTable(er *errReader, cf *ClassFile) *attributeLocalVariableTable {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.13
+	attr := attributeLocalVariableTable{attributeInfoBase: *base}
+	if item(er, "local_variable_table_length", integer(&attr.localVariableTableLength)) {
+		attr.localVariableTable = makeTable[localVariable](er, cf, int(attr.localVariableTableLength))
+		item(er, "local_variable_table", entries(attr.localVariableTable, func(er *errReader) localVariable {
+			var v localVariable
+			item(er, "start_pc", integer(&v.startPC))
+			item(er, "length", integer(&v.length))
+			item(er, "name_index", integer(&v.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf)))
+			item(er, "descriptor_index", integer(&v.descriptorIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), fieldDescriptor(cf)))
+			item(er, "index", integer(&v.index))
+			return v
+		}))
+	}
+	return &attr
+}
+
+type localVariableType struct {
+	startPC        uint16
+	length         uint16
+	nameIndex      uint16
+	signatureIndex uint16
+	index          uint16
+}
+
+type attributeLocalVariableTypeTable struct {
+	attributeInfoBase
+	localVariableTypeTableLength uint16
+	localVariableTypeTable       []localVariableType
+}
+
+func (base *attributeInfoBase) localVariableTypeTable(er *errReader, cf *ClassFile) *attributeLocalVariableTypeTable {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.14
+	attr := attributeLocalVariableTypeTable{attributeInfoBase: *base}
+	if item(er, "local_variable_type_table_length", integer(&attr.localVariableTypeTableLength)) {
+		attr.localVariableTypeTable = makeTable[localVariableType](er, cf, int(attr.localVariableTypeTableLength))
+		item(er, "local_variable_type_table", entries(attr.localVariableTypeTable, func(er *errReader) localVariableType {
+			var v localVariableType
+			item(er, "start_pc", integer(&v.startPC))
+			item(er, "length", integer(&v.length))
+			item(er, "name_index", integer(&v.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf)))
+			item(er, "signature_index", integer(&v.signatureIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), fieldSignature(cf)))
+			item(er, "index", integer(&v.index))
+			return v
+		}))
+	}
+	return &attr
+}
+
+// Code is the Code attribute of a method, which contains the bytecode instructions
+// and auxiliary information of the method.
+type Code struct {
+	cf   *ClassFile
+	attr *attributeCode
+}
+
+// MaxStack returns the maximum depth of the operand stack of the method.
+func (c *Code) MaxStack() uint16 { return c.attr.maxStack }
+
+// MaxLocals returns the number of local variables, including the method parameters.
+func (c *Code) MaxLocals() uint16 { return c.attr.maxLocals }
+
+// Bytecode returns the bytecode instructions of the method, which may share the memory with the input of ParseBytes.
+func (c *Code) Bytecode() []byte { return c.attr.code }
+
+// BytecodeOffset returns the byte offset of the bytecode in the class file,
+// so that the instruction at pc starts at BytecodeOffset() + pc.
+func (c *Code) BytecodeOffset() int64 { return c.attr.codeOffset }
+
+// ExceptionHandler is an entry of the exception_table of a Code attribute.
+type ExceptionHandler struct {
+	// StartPC and EndPC indicate the range [StartPC, EndPC) in which the handler is active.
+	StartPC, EndPC uint16
+	HandlerPC      uint16
+	// CatchType is the name of the exception class to catch,
+	// or an empty string if the handler is called for all exceptions, e.g. finally.
+	CatchType string
+}
+
+// ExceptionTable returns the exception handlers in the order of the exception_table.
+func (c *Code) ExceptionTable() []ExceptionHandler {
+	handlers := make([]ExceptionHandler, len(c.attr.exceptionTable))
+	for i, e := range c.attr.exceptionTable {
+		handlers[i] = ExceptionHandler{StartPC: e.startPC, EndPC: e.endPC, HandlerPC: e.handlerPC}
+		if e.catchType != 0 {
+			handlers[i].CatchType = c.cf.className(e.catchType)
+		}
+	}
+	return handlers
+}
+
+// Attributes returns the attributes of the Code attribute, such as LineNumberTable.
+func (c *Code) Attributes() []Attribute {
+	attrs := make([]Attribute, len(c.attr.attributes))
+	for i, a := range c.attr.attributes {
+		attrs[i] = resolveAttribute(a)
+	}
+	return attrs
+}
+
+// TypeAnnotations returns the type annotations in the method body,
+// such as on local variable declarations or casts.
+func (c *Code) TypeAnnotations() []TypeAnnotation {
+	return typeAnnotations(c.cf, c.attr.attributes)
+}
+
+// LineNumber is an entry of the LineNumberTable attribute.
+type LineNumber struct {
+	StartPC uint16
+	Line    uint16
+}
+
+// LineNumberTable returns the entries of all the LineNumberTable attributes ordered by StartPC.
+func (c *Code) LineNumberTable() []LineNumber {
+	var lines []LineNumber
+	for _, a := range c.attr.attributes {
+		if attr, ok := a.(*attributeLineNumberTable); ok {
+			for _, l := range attr.lineNumberTable {
+				lines = append(lines, LineNumber{StartPC: l.startPC, Line: l.lineNumber})
+			}
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].StartPC < lines[j].StartPC })
+	return lines
+}
+
+// LineForPC returns the source line number of the instruction at pc.
+// ok is false if no line number is recorded for pc.
+func (c *Code) LineForPC(pc int) (line int, ok bool) {
+	if pc < 0 || len(c.attr.code) <= pc {
+		return 0, false
+	}
+	for _, l := range c.LineNumberTable() {
+		if pc < int(l.StartPC) {
+			break
+		}
+		line, ok = int(l.Line), true
+	}
+	return line, ok
+}
+
+// PCRange is a range of bytecode offsets [Start, End).
+type PCRange struct {
+	Start, End int
+}
+
+// PCRangesForLine returns the ranges of bytecode offsets of the instructions compiled from the source line.
+func (c *Code) PCRangesForLine(line int) []PCRange {
+	lines := c.LineNumberTable()
+
+	var ranges []PCRange
+	for i, l := range lines {
+		if int(l.Line) != line {
+			continue
+		}
+		r := PCRange{Start: int(l.StartPC), End: len(c.attr.code)}
+		for _, next := range lines[i+1:] {
+			if l.StartPC < next.StartPC {
+				r.End = int(next.StartPC)
+				break
+			}
+		}
+		if r.End <= r.Start {
+			continue
+		}
+		if n := len(ranges); 0 < n && ranges[n-1].End == r.Start {
+			ranges[n-1].End = r.End
+		} else {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// LocalVariable is a local variable recorded in the LocalVariableTable attribute.
+type LocalVariable struct {
+	// StartPC and Length indicate the range [StartPC, StartPC+Length) in which the variable has a value.
+	StartPC, Length uint16
+	Name            string
+	Descriptor      string
+	// Signature is the generic signature from the LocalVariableTypeTable attribute,
+	// or an empty string if the type of the variable is not generic.
+	Signature string
+	// Index is the index of the variable in the local variable array of the current frame.
+	Index uint16
+}
+
+// LocalVariables returns the entries of all the LocalVariableTable attributes
+// in the order they appear in the class file.
+func (c *Code) LocalVariables() []LocalVariable {
+	var signatures []localVariableType
+	for _, a := range c.attr.attributes {
+		if attr, ok := a.(*attributeLocalVariableTypeTable); ok {
+			signatures = append(signatures, attr.localVariableTypeTable...)
+		}
+	}
+
+	var vars []LocalVariable
+	for _, a := range c.attr.attributes {
+		attr, ok := a.(*attributeLocalVariableTable)
+		if !ok {
+			continue
+		}
+		for _, e := range attr.localVariableTable {
+			v := LocalVariable{
+				StartPC:    e.startPC,
+				Length:     e.length,
+				Name:       c.cf.utf8(e.nameIndex),
+				Descriptor: c.cf.utf8(e.descriptorIndex),
+				Index:      e.index,
+			}
+			for _, s := range signatures {
+				if s.startPC == e.startPC && s.length == e.length && s.index == e.index {
+					v.Signature = c.cf.utf8(s.signatureIndex)
+					break
+				}
+			}
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
+
+// LocalsAt returns the local variables which have a value at pc, ordered by their index.
+func (c *Code) LocalsAt(pc int) []LocalVariable {
+	var vars []LocalVariable
+	for _, v := range c.LocalVariables() {
+		if int(v.StartPC) <= pc && pc < int(v.StartPC)+int(v.Length) {
+			vars = append(vars, v)
+		}
+	}
+	sort.SliceStable(vars, func(i, j int) bool { return vars[i].Index < vars[j].Index })
+	return vars
+}
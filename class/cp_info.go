@@ -2,9 +2,11 @@ package class
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
-	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 type ConstantKind = byte
@@ -29,277 +31,645 @@ const (
 	ConstantKindPackage                         = 20
 )
 
-type cpInfo interface {
+// Constant is an entry of the constant_pool table.
+type Constant interface {
+	// Tag returns the kind of the entry, or 0 for ConstantUnusable.
 	Tag() byte
+	// String returns the entry referring to the other entries by their indexes, such as "#2.#3".
 	String() string
+	// Symbolic returns the entry with its references resolved, such as `java/lang/Object."<init>":()V`.
+	Symbolic() string
+	// Span returns the bytes of the cp_info structure, or a zero Span for ConstantUnusable.
+	Span() Span
 }
 
-func parseCpInfo(r *errReader) cpInfo {
-	var tag cpInfoTag
+func constantPool(cf *ClassFile, cp []Constant) func(e *errReader) bool {
+	return func(e *errReader) bool {
+		return readConstantPool(e, cf, cp)
+	}
+}
 
-	item(r, "cp_info tag", integer(&tag.tag))
+func readConstantPool(e *errReader, cf *ClassFile, cp []Constant) bool {
+	if e.err != nil {
+		return false
+	}
+	er := new(errReader)
+	for i := 0; i < len(cp); i++ {
+		// The constant_pool table is indexed from 1 to constant_pool_count - 1
+		er.reset(e, i+1)
+		offset := er.r.n
+		entry := parseCpInfo(er, cf)
+		if er.err != nil {
+			e.err = er.err
+			return false
+		}
+		entry.(interface{ setSpan(Span) }).setSpan(spanFrom(er, offset))
+		cp[i] = entry
+
+		// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4.5
+		// CONSTANT_Long_info and CONSTANT_Double_info structures take up two entries
+		switch entry.Tag() {
+		case ConstantKindLong, ConstantKindDouble:
+			if len(cp) <= i+1 {
+				// the second entry would be at constant_pool_count, which is not a valid index
+				er.name, er.offset = "", offset
+				er.fail(fmt.Errorf("%w: the last entry takes up two entries beyond constant_pool_count", ErrBadConstantPoolReference))
+				e.err = er.err
+				return false
+			}
+			i++
+			cp[i] = &ConstantUnusable{}
+		}
+	}
+	return true
+}
+
+// constantPoolReferences cross-checks the indexes in every constant_pool entry against the entries they refer to,
+// as the format checking of JVMS 4.8 does. It runs after the whole class file is read since an entry can refer to
+// later entries, and CONSTANT_Dynamic_info and CONSTANT_InvokeDynamic_info refer to the BootstrapMethods attribute.
+// All the violations are reported together as ParseErrors.
+func constantPoolReferences(cf *ClassFile) func(e *errReader) bool {
+	return func(e *errReader) bool {
+		var errs ParseErrors
+		er := new(errReader)
+		check := func(i int, name string, offset int64, index uint16, vs ...validator[uint16]) {
+			er.reset(e, i+1)
+			er.name, er.offset = name, cf.ConstantPool[i].Span().Offset+offset
+			validate(er, index, vs...)
+			if er.err != nil {
+				errs = append(errs, er.err.(*ParseError))
+			}
+		}
+		// the validators of NameAndType by the kind of the referring entry
+		nameAndTypes := map[ConstantKind]validator[uint16]{}
+		for _, kind := range []ConstantKind{ConstantKindFieldref, ConstantKindMethodref, ConstantKindInterfaceMethodref, ConstantKindDynamic, ConstantKindInvokeDynamic} {
+			nameAndTypes[kind] = memberNameAndType(cf, kind)
+		}
+		checkMemberRef := func(i int, m *memberRef) {
+			check(i, "class_index", 1, m.classIndex, constantPoolStructure[uint16, *ConstantClass](cf))
+			check(i, "name_and_type_index", 3, m.nameAndTypeIndex, nameAndTypes[m.tag])
+		}
+		checkDynamicRef := func(i int, d *dynamicRef) {
+			check(i, "bootstrap_method_attr_index", 1, d.bootstrapMethodAttrIndex, bootstrapMethodAttr(cf))
+			check(i, "name_and_type_index", 3, d.nameAndTypeIndex, nameAndTypes[d.tag])
+		}
+		for i, entry := range cf.ConstantPool {
+			switch c := entry.(type) {
+			case *ConstantClass:
+				check(i, "name_index", 1, c.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), classEntryName(cf))
+			case *ConstantFieldref:
+				checkMemberRef(i, &c.memberRef)
+			case *ConstantMethodref:
+				checkMemberRef(i, &c.memberRef)
+			case *ConstantInterfaceMethodref:
+				checkMemberRef(i, &c.memberRef)
+			case *ConstantString:
+				check(i, "string_index", 1, c.stringIndex, constantPoolStructure[uint16, *ConstantUtf8](cf))
+			case *ConstantNameAndType:
+				check(i, "name_index", 1, c.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf))
+				check(i, "descriptor_index", 3, c.descriptorIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), anyDescriptor(cf))
+			case *ConstantMethodHandle:
+				check(i, "reference_kind", 1, uint16(c.referenceKind), min[uint16](uint16(RefGetField)), max[uint16](uint16(RefInvokeInterface)))
+				check(i, "reference_index", 2, c.referenceIndex, methodHandleReference(cf, c.Kind()))
+			case *ConstantMethodType:
+				check(i, "descriptor_index", 1, c.descriptorIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), methodDescriptor(cf, true))
+			case *ConstantDynamic:
+				checkDynamicRef(i, &c.dynamicRef)
+			case *ConstantInvokeDynamic:
+				checkDynamicRef(i, &c.dynamicRef)
+			case *ConstantModule:
+				check(i, "name_index", 1, c.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), moduleEntryName(cf))
+			case *ConstantPackage:
+				check(i, "name_index", 1, c.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), packageEntryName(cf))
+			}
+		}
+		if 0 < len(errs) {
+			e.fail(errs)
+			return false
+		}
+		return true
+	}
+}
+
+func parseCpInfo(r *errReader, cf *ClassFile) Constant {
+	tag := cpInfoTag{cf: cf}
+
+	item(r, "cp_info tag", integer(&tag.tag, constantKindVersion(cf)))
 
 	switch tag.tag {
 	case ConstantKindClass:
-		c := constantClass{cpInfoTag: tag}
+		c := ConstantClass{cpInfoTag: tag}
 		item(r, "CONSTANT_Class_info's name_index", integer(&c.nameIndex))
 		return &c
 	case ConstantKindFieldref:
-		c := constantFieldref{cpInfoTag: tag}
+		c := ConstantFieldref{memberRef{cpInfoTag: tag}}
 		item(r, "CONSTANT_Fieldref_info's class_index", integer(&c.classIndex))
 		item(r, "CONSTANT_Fieldref_info's name_and_type_index", integer(&c.nameAndTypeIndex))
 		return &c
 	case ConstantKindMethodref:
-		c := constantMethodref{cpInfoTag: tag}
+		c := ConstantMethodref{memberRef{cpInfoTag: tag}}
 		item(r, "CONSTANT_Methodref_info's class_index", integer(&c.classIndex))
 		item(r, "CONSTANT_Methodref_info's name_and_type_index", integer(&c.nameAndTypeIndex))
 		return &c
 	case ConstantKindInterfaceMethodref:
-		c := constantInterfaceMethodref{cpInfoTag: tag}
+		c := ConstantInterfaceMethodref{memberRef{cpInfoTag: tag}}
 		item(r, "CONSTANT_InterfaceMethodref_info's class_index", integer(&c.classIndex))
 		item(r, "CONSTANT_InterfaceMethodref_info's name_and_type_index", integer(&c.nameAndTypeIndex))
 		return &c
 	case ConstantKindString:
-		c := constantString{cpInfoTag: tag}
+		c := ConstantString{cpInfoTag: tag}
 		item(r, "CONSTANT_String_info's string_index", integer(&c.stringIndex))
 		return &c
 	case ConstantKindInteger:
-		c := constantInteger{cpInfoTag: tag}
+		c := ConstantInteger{cpInfoTag: tag}
 		item(r, "CONSTANT_Integer_info's bytes", bytes(c.bytes[:]))
 		return &c
 	case ConstantKindFloat:
-		c := constantFloat{cpInfoTag: tag}
+		c := ConstantFloat{cpInfoTag: tag}
 		item(r, "CONSTANT_Float_info's bytes", bytes(c.bytes[:]))
 		return &c
 	case ConstantKindLong:
-		c := constantLong{cpInfoTag: tag}
+		c := ConstantLong{cpInfoTag: tag}
 		item(r, "CONSTANT_Long_info's high_bytes", bytes(c.high[:]))
 		item(r, "CONSTANT_Long_info's low_bytes", bytes(c.low[:]))
 		return &c
 	case ConstantKindDouble:
-		c := constantDouble{cpInfoTag: tag}
+		c := ConstantDouble{cpInfoTag: tag}
 		item(r, "CONSTANT_Double_info's high_bytes", bytes(c.high[:]))
 		item(r, "CONSTANT_Double_info's low_bytes", bytes(c.low[:]))
 		return &c
 	case ConstantKindNameAndType:
-		c := constantNameAndType{cpInfoTag: tag}
+		c := ConstantNameAndType{cpInfoTag: tag}
 		item(r, "CONSTANT_NameAndType_info's name_index", integer(&c.nameIndex))
 		item(r, "CONSTANT_NameAndType_info's descriptor_index", integer(&c.descriptorIndex))
 		return &c
 	case ConstantKindUtf8:
-		c := constantUtf8{cpInfoTag: tag}
+		c := ConstantUtf8{cpInfoTag: tag}
 		item(r, "CONSTANT_Utf8_info's length", integer(&c.length))
-		c.bytes = make([]byte, c.length)
-		item(r, "CONSTANT_Utf8_info's bytes", bytes(c.bytes))
+		if item(r, "CONSTANT_Utf8_info's bytes", slice(&c.bytes, int(c.length))) {
+			if err := validateModifiedUTF8(c.bytes); err != nil {
+				r.fail(err)
+				return nil
+			}
+		}
 		return &c
 	case ConstantKindMethodHandle:
-		c := constantMethodHandle{cpInfoTag: tag}
+		c := ConstantMethodHandle{cpInfoTag: tag}
 		item(r, "CONSTANT_MethodHandle_info's reference_kind", integer(&c.referenceKind))
 		item(r, "CONSTANT_MethodHandle_info's reference_index", integer(&c.referenceIndex))
 		return &c
 	case ConstantKindMethodType:
-		c := constantMethodType{cpInfoTag: tag}
+		c := ConstantMethodType{cpInfoTag: tag}
 		item(r, "CONSTANT_MethodType_info's descriptor_index", integer(&c.descriptorIndex))
 		return &c
 	case ConstantKindDynamic:
-		c := constantDynamic{cpInfoTag: tag}
+		c := ConstantDynamic{dynamicRef{cpInfoTag: tag}}
 		item(r, "CONSTANT_Dynamic_info's bootstrap_method_attr_index", integer(&c.bootstrapMethodAttrIndex))
 		item(r, "CONSTANT_Dynamic_info's name_and_type_index", integer(&c.nameAndTypeIndex))
 		return &c
 	case ConstantKindInvokeDynamic:
-		c := constantInvokeDynamic{cpInfoTag: tag}
+		c := ConstantInvokeDynamic{dynamicRef{cpInfoTag: tag}}
 		item(r, "CONSTANT_InvokeDynamic_info's bootstrap_method_attr_index", integer(&c.bootstrapMethodAttrIndex))
 		item(r, "CONSTANT_InvokeDynamic_info's name_and_type_index", integer(&c.nameAndTypeIndex))
 		return &c
 	case ConstantKindModule:
-		c := constantModule{cpInfoTag: tag}
+		c := ConstantModule{cpInfoTag: tag}
 		item(r, "CONSTANT_Module_info's name_index", integer(&c.nameIndex))
 		return &c
 	case ConstantKindPackage:
-		c := constantPackage{cpInfoTag: tag}
-		item(r, "CONSTANT_Module_info's name_index", integer(&c.nameIndex))
+		c := ConstantPackage{cpInfoTag: tag}
+		item(r, "CONSTANT_Package_info's name_index", integer(&c.nameIndex))
 		return &c
 	}
-	r.err = fmt.Errorf("unsupported tag for cp_info: %d", tag.tag)
+	r.fail(fmt.Errorf("unsupported tag for cp_info: %d", tag.tag))
 	return nil
 }
 
 type cpInfoTag struct {
 	tag byte
+
+	cf   *ClassFile
+	span Span
 }
 
 func (c *cpInfoTag) Tag() byte { return c.tag }
 
-type constantClass struct {
+func (c *cpInfoTag) Span() Span { return c.span }
+
+func (c *cpInfoTag) setSpan(s Span) { c.span = s }
+
+func (c *cpInfoTag) utf8(i uint16) string {
+	e, err := lookupCpinfo[*ConstantUtf8](c.cf, i)
+	if err != nil {
+		return ""
+	}
+	return e.String()
+}
+
+func (c *cpInfoTag) className(i uint16) string {
+	e, err := lookupCpinfo[*ConstantClass](c.cf, i)
+	if err != nil {
+		return ""
+	}
+	return e.Name()
+}
+
+func (c *cpInfoTag) nameAndType(i uint16) *ConstantNameAndType {
+	e, err := lookupCpinfo[*ConstantNameAndType](c.cf, i)
+	if err != nil {
+		return &ConstantNameAndType{cpInfoTag: cpInfoTag{tag: ConstantKindNameAndType, cf: c.cf}}
+	}
+	return e
+}
+
+// ConstantClass is a CONSTANT_Class_info structure, which represents a class or an interface.
+type ConstantClass struct {
 	cpInfoTag
 	nameIndex uint16
 }
 
-type constantFieldref struct {
+// Name returns the binary name of the class in internal form, such as "java/lang/Object",
+// or the descriptor of the array type, such as "[Ljava/lang/String;".
+func (c *ConstantClass) Name() string { return c.utf8(c.nameIndex) }
+
+type memberRef struct {
 	cpInfoTag
 	classIndex       uint16
 	nameAndTypeIndex uint16
 }
 
-type constantMethodref struct {
-	cpInfoTag
-	classIndex       uint16
-	nameAndTypeIndex uint16
+// Class returns the class or interface of which the member is a member.
+func (c *memberRef) Class() *ConstantClass {
+	e, err := lookupCpinfo[*ConstantClass](c.cf, c.classIndex)
+	if err != nil {
+		return &ConstantClass{cpInfoTag: cpInfoTag{tag: ConstantKindClass, cf: c.cf}}
+	}
+	return e
 }
 
-type constantInterfaceMethodref struct {
-	cpInfoTag
-	classIndex       uint16
-	nameAndTypeIndex uint16
+// Owner returns the name of the class or interface of which the member is a member.
+func (c *memberRef) Owner() string { return c.className(c.classIndex) }
+
+// NameAndType returns the name and descriptor of the member.
+func (c *memberRef) NameAndType() *ConstantNameAndType { return c.nameAndType(c.nameAndTypeIndex) }
+
+// Name returns the name of the member.
+func (c *memberRef) Name() string { return c.NameAndType().Name() }
+
+// Descriptor returns the field or method descriptor of the member.
+func (c *memberRef) Descriptor() string { return c.NameAndType().Descriptor() }
+
+func (c *memberRef) String() string {
+	return fmt.Sprintf("#%d.#%d", c.classIndex, c.nameAndTypeIndex)
 }
 
-type constantString struct {
+func (c *memberRef) Symbolic() string {
+	return fmt.Sprintf("%s.%s", c.Class().Symbolic(), c.NameAndType().Symbolic())
+}
+
+// ConstantFieldref is a CONSTANT_Fieldref_info structure, which represents a field.
+type ConstantFieldref struct {
+	memberRef
+}
+
+// ConstantMethodref is a CONSTANT_Methodref_info structure, which represents a method of a class.
+type ConstantMethodref struct {
+	memberRef
+}
+
+// ConstantInterfaceMethodref is a CONSTANT_InterfaceMethodref_info structure, which represents a method of an interface.
+type ConstantInterfaceMethodref struct {
+	memberRef
+}
+
+// ConstantString is a CONSTANT_String_info structure, which represents a constant object of the type String.
+type ConstantString struct {
 	cpInfoTag
 	stringIndex uint16
 }
 
-type constantInteger struct {
+// Value returns the string value.
+func (c *ConstantString) Value() string { return c.utf8(c.stringIndex) }
+
+// ConstantInteger is a CONSTANT_Integer_info structure, which represents an int constant.
+type ConstantInteger struct {
 	cpInfoTag
 	bytes [4]byte
 }
 
-type constantFloat struct {
+// ConstantFloat is a CONSTANT_Float_info structure, which represents a float constant.
+type ConstantFloat struct {
 	cpInfoTag
 	bytes [4]byte
 }
 
-type constantLong struct {
+// ConstantLong is a CONSTANT_Long_info structure, which represents a long constant.
+type ConstantLong struct {
 	cpInfoTag
 	high [4]byte
 	low  [4]byte
 }
 
-type constantDouble struct {
+// ConstantDouble is a CONSTANT_Double_info structure, which represents a double constant.
+type ConstantDouble struct {
 	cpInfoTag
 	high [4]byte
 	low  [4]byte
 }
 
-type constantNameAndType struct {
+// ConstantNameAndType is a CONSTANT_NameAndType_info structure, which represents a field or method
+// without indicating which class or interface type it belongs to.
+type ConstantNameAndType struct {
 	cpInfoTag
 	nameIndex       uint16
 	descriptorIndex uint16
 }
 
-type constantUtf8 struct {
+// Name returns the name of the field or method.
+func (c *ConstantNameAndType) Name() string { return c.utf8(c.nameIndex) }
+
+// Descriptor returns the field or method descriptor.
+func (c *ConstantNameAndType) Descriptor() string { return c.utf8(c.descriptorIndex) }
+
+// ConstantUtf8 is a CONSTANT_Utf8_info structure, which represents a constant string value.
+type ConstantUtf8 struct {
 	cpInfoTag
 	length uint16
-	bytes  []byte
+	// bytes may share the memory with the input of ParseBytes, and is decoded on first use
+	bytes []byte
+
+	once  sync.Once
+	value string
 }
 
-type constantMethodHandle struct {
+// ConstantMethodHandle is a CONSTANT_MethodHandle_info structure, which represents a method handle.
+type ConstantMethodHandle struct {
 	cpInfoTag
 	referenceKind  byte
 	referenceIndex uint16
 }
 
-type constantMethodType struct {
+// Kind returns the kind of the method handle, which characterizes its bytecode behavior.
+func (c *ConstantMethodHandle) Kind() ReferenceKind { return ReferenceKind(c.referenceKind) }
+
+// Reference returns the field or method referred by the method handle,
+// which is a *ConstantFieldref, *ConstantMethodref or *ConstantInterfaceMethodref.
+func (c *ConstantMethodHandle) Reference() Constant {
+	e, ok := c.cf.lookupConstantPool(c.referenceIndex)
+	if !ok {
+		return nil
+	}
+	return e
+}
+
+// ReferenceKind is the kind of a method handle.
+type ReferenceKind uint8
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-5.html#jvms-5.4.3.5-220
+const (
+	RefGetField         ReferenceKind = 1
+	RefGetStatic        ReferenceKind = 2
+	RefPutField         ReferenceKind = 3
+	RefPutStatic        ReferenceKind = 4
+	RefInvokeVirtual    ReferenceKind = 5
+	RefInvokeStatic     ReferenceKind = 6
+	RefInvokeSpecial    ReferenceKind = 7
+	RefNewInvokeSpecial ReferenceKind = 8
+	RefInvokeInterface  ReferenceKind = 9
+)
+
+func (k ReferenceKind) String() string {
+	switch k {
+	case RefGetField:
+		return "REF_getField"
+	case RefGetStatic:
+		return "REF_getStatic"
+	case RefPutField:
+		return "REF_putField"
+	case RefPutStatic:
+		return "REF_putStatic"
+	case RefInvokeVirtual:
+		return "REF_invokeVirtual"
+	case RefInvokeStatic:
+		return "REF_invokeStatic"
+	case RefInvokeSpecial:
+		return "REF_invokeSpecial"
+	case RefNewInvokeSpecial:
+		return "REF_newInvokeSpecial"
+	case RefInvokeInterface:
+		return "REF_invokeInterface"
+	}
+	return fmt.Sprintf("ReferenceKind(%d)", uint8(k))
+}
+
+// ConstantMethodType is a CONSTANT_MethodType_info structure, which represents a method type.
+type ConstantMethodType struct {
 	cpInfoTag
 	descriptorIndex uint16
 }
 
-type constantDynamic struct {
+// Descriptor returns the method descriptor of the method type.
+func (c *ConstantMethodType) Descriptor() string { return c.utf8(c.descriptorIndex) }
+
+type dynamicRef struct {
 	cpInfoTag
 	bootstrapMethodAttrIndex uint16
 	nameAndTypeIndex         uint16
 }
 
-type constantInvokeDynamic struct {
-	cpInfoTag
-	bootstrapMethodAttrIndex uint16
-	nameAndTypeIndex         uint16
+// BootstrapMethodAttrIndex returns the index into the bootstrap_methods array of the BootstrapMethods attribute.
+func (c *dynamicRef) BootstrapMethodAttrIndex() uint16 { return c.bootstrapMethodAttrIndex }
+
+// NameAndType returns the name and descriptor of the dynamically-computed constant or call site.
+func (c *dynamicRef) NameAndType() *ConstantNameAndType { return c.nameAndType(c.nameAndTypeIndex) }
+
+// Name returns the name of the dynamically-computed constant or call site.
+func (c *dynamicRef) Name() string { return c.NameAndType().Name() }
+
+// Descriptor returns the field descriptor of the dynamically-computed constant,
+// or the method descriptor of the dynamically-computed call site.
+func (c *dynamicRef) Descriptor() string { return c.NameAndType().Descriptor() }
+
+func (c *dynamicRef) String() string {
+	return fmt.Sprintf("#%d:#%d", c.bootstrapMethodAttrIndex, c.nameAndTypeIndex)
+}
+
+func (c *dynamicRef) Symbolic() string {
+	return fmt.Sprintf("#%d:%s", c.bootstrapMethodAttrIndex, c.NameAndType().Symbolic())
+}
+
+// ConstantDynamic is a CONSTANT_Dynamic_info structure, which represents a dynamically-computed constant.
+type ConstantDynamic struct {
+	dynamicRef
+}
+
+// ConstantInvokeDynamic is a CONSTANT_InvokeDynamic_info structure,
+// which represents a dynamically-computed call site for an invokedynamic instruction.
+type ConstantInvokeDynamic struct {
+	dynamicRef
 }
 
-type constantModule struct {
+// ConstantUnusable is the entry following a CONSTANT_Long_info or CONSTANT_Double_info structure,
+// which is valid but considered unusable.
+type ConstantUnusable struct{}
+
+func (*ConstantUnusable) Tag() byte { return 0 }
+
+func (*ConstantUnusable) Span() Span { return Span{} }
+
+// ConstantModule is a CONSTANT_Module_info structure, which represents a module.
+type ConstantModule struct {
 	cpInfoTag
 	nameIndex uint16
 }
 
-type constantPackage struct {
+// Name returns the name of the module.
+func (c *ConstantModule) Name() string { return c.utf8(c.nameIndex) }
+
+// ConstantPackage is a CONSTANT_Package_info structure, which represents a package exported or opened by a module.
+type ConstantPackage struct {
 	cpInfoTag
 	nameIndex uint16
 }
 
-func (c *constantClass) String() string { return fmt.Sprintf("#%d", c.nameIndex) }
-func (c *constantFieldref) String() string {
-	return fmt.Sprintf("#%d.#%d", c.classIndex, c.nameAndTypeIndex)
+// Name returns the name of the package in internal form, such as "java/lang".
+func (c *ConstantPackage) Name() string { return c.utf8(c.nameIndex) }
+
+func (c *ConstantClass) String() string { return fmt.Sprintf("#%d", c.nameIndex) }
+func (c *ConstantString) String() string {
+	return fmt.Sprintf("#%d", c.stringIndex)
 }
-func (c *constantMethodref) String() string {
-	return fmt.Sprintf("#%d.#%d", c.classIndex, c.nameAndTypeIndex)
+func (c *ConstantInteger) String() string {
+	return strconv.FormatInt(int64(c.Int32()), 10)
 }
-func (c *constantInterfaceMethodref) String() string {
-	return fmt.Sprintf("#%d.#%d", c.classIndex, c.nameAndTypeIndex)
+func (c *ConstantFloat) String() string {
+	return formatJavaFloat(float64(c.Float32()), 32, "f")
 }
-func (c *constantString) String() string {
-	return fmt.Sprintf("#%d", c.stringIndex)
+func (c *ConstantLong) String() string {
+	return strconv.FormatInt(c.Int64(), 10) + "L"
 }
-func (c *constantInteger) String() string {
-	//TODO
-	return fmt.Sprintf("%v", c.bytes)
+func (c *ConstantDouble) String() string {
+	return formatJavaFloat(c.Float64(), 64, "d")
 }
-func (c *constantFloat) String() string {
-	//TODO
-	return fmt.Sprintf("%v", c.bytes)
+func (c *ConstantNameAndType) String() string {
+	return fmt.Sprintf("#%v:#%v", c.nameIndex, c.descriptorIndex)
 }
-func (c *constantLong) String() string {
-	//TODO
-	return fmt.Sprintf("%v %v", c.high, c.low)
+func (c *ConstantUtf8) String() string {
+	c.once.Do(func() {
+		// the bytes have been validated while parsing
+		c.value, _ = DecodeModifiedUTF8(c.bytes)
+	})
+	return c.value
 }
-func (c *constantDouble) String() string {
-	//TODO
-	return fmt.Sprintf("%v %v", c.high, c.low)
+func (c *ConstantMethodHandle) String() string {
+	return fmt.Sprintf("%s #%d", c.Kind(), c.referenceIndex)
 }
-func (c *constantNameAndType) String() string {
-	return fmt.Sprintf("#%v:#%v", c.nameIndex, c.descriptorIndex)
+func (c *ConstantMethodType) String() string {
+	return fmt.Sprintf("#%d", c.descriptorIndex)
 }
-func (c *constantUtf8) String() string {
-	return fmt.Sprintf("%s", c.bytes)
+func (c *ConstantUnusable) String() string {
+	return "(unusable)"
 }
-func (c *constantMethodHandle) String() string {
-	return fmt.Sprintf("%d %d", c.referenceKind, c.referenceIndex)
+func (c *ConstantModule) String() string {
+	return fmt.Sprintf("#%d", c.nameIndex)
 }
-func (c *constantMethodType) String() string {
-	return fmt.Sprintf("%d", c.descriptorIndex)
+func (c *ConstantPackage) String() string {
+	return fmt.Sprintf("#%d", c.nameIndex)
 }
-func (c *constantDynamic) String() string {
-	return fmt.Sprintf("%d %d", c.bootstrapMethodAttrIndex, c.nameAndTypeIndex)
+
+func (c *ConstantClass) Symbolic() string {
+	name := c.Name()
+	if strings.HasPrefix(name, "[") {
+		return strconv.Quote(name)
+	}
+	return name
+}
+func (c *ConstantString) Symbolic() string     { return c.Value() }
+func (c *ConstantInteger) Symbolic() string    { return c.String() }
+func (c *ConstantFloat) Symbolic() string      { return c.String() }
+func (c *ConstantLong) Symbolic() string       { return c.String() }
+func (c *ConstantDouble) Symbolic() string     { return c.String() }
+func (c *ConstantUtf8) Symbolic() string       { return c.String() }
+func (c *ConstantMethodType) Symbolic() string { return c.Descriptor() }
+func (c *ConstantUnusable) Symbolic() string   { return c.String() }
+func (c *ConstantModule) Symbolic() string     { return c.Name() }
+func (c *ConstantPackage) Symbolic() string    { return c.Name() }
+func (c *ConstantNameAndType) Symbolic() string {
+	name := c.Name()
+	// special method names such as <init> are quoted as javap does
+	if strings.HasPrefix(name, "<") {
+		name = strconv.Quote(name)
+	}
+	return fmt.Sprintf("%s:%s", name, c.Descriptor())
+}
+func (c *ConstantMethodHandle) Symbolic() string {
+	ref := c.Reference()
+	if ref == nil {
+		return c.Kind().String()
+	}
+	return fmt.Sprintf("%s %s", c.Kind(), ref.Symbolic())
+}
+
+// Int32 returns the value of the int constant.
+func (c *ConstantInteger) Int32() int32 {
+	return int32(binary.BigEndian.Uint32(c.bytes[:]))
 }
-func (c *constantInvokeDynamic) String() string {
-	return fmt.Sprintf("%d %d", c.bootstrapMethodAttrIndex, c.nameAndTypeIndex)
+
+// booleanValue converts the int value of a boolean to bool, narrowing it by the lowest bit as the JVM does
+// when it stores an int into a boolean field or array, so that a value such as 2 is false.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-6.html#jvms-6.5.putstatic
+func booleanValue(v int32) bool {
+	return v&1 != 0
 }
-func (c *constantModule) String() string {
-	return fmt.Sprintf("%d", c.nameIndex)
+
+// Float32 returns the value of the float constant.
+func (c *ConstantFloat) Float32() float32 {
+	return math.Float32frombits(binary.BigEndian.Uint32(c.bytes[:]))
 }
-func (c *constantPackage) String() string {
-	return fmt.Sprintf("%d", c.nameIndex)
+
+// Int64 returns the value of the long constant.
+func (c *ConstantLong) Int64() int64 {
+	return int64(uint64(binary.BigEndian.Uint32(c.high[:]))<<32 | uint64(binary.BigEndian.Uint32(c.low[:])))
 }
 
-type parser struct {
-	Err error
+// Float64 returns the value of the double constant.
+func (c *ConstantDouble) Float64() float64 {
+	return math.Float64frombits(uint64(binary.BigEndian.Uint32(c.high[:]))<<32 | uint64(binary.BigEndian.Uint32(c.low[:])))
 }
 
-func (p *parser) readInteger(r io.Reader, data any, name string) error {
-	if p.Err != nil {
-		return p.Err
+// formatJavaFloat formats a float or double value as Float.toString and Double.toString of Java do,
+// followed by the suffix unless the value is NaN or infinite.
+func formatJavaFloat(v float64, bitSize int, suffix string) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
 	}
-	if err := binary.Read(r, binary.BigEndian, data); err != nil {
-		p.Err = fmt.Errorf("fail to parse %s: %w", name, err)
+
+	abs := math.Abs(v)
+	if v == 0 || (1e-3 <= abs && abs < 1e7) {
+		s := strconv.FormatFloat(v, 'f', -1, bitSize)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s + suffix
 	}
-	return p.Err
-}
 
-func (p *parser) readBytes(r io.Reader, bytes []byte, name string) {
-	if p.Err != nil {
-		return
+	// computerized scientific notation, e.g. 1.0E10
+	s := strconv.FormatFloat(v, 'e', -1, bitSize)
+	mantissa, exp, _ := strings.Cut(s, "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
 	}
-	if n, err := r.Read(bytes); err != nil {
-		p.Err = fmt.Errorf("fail to parse %s: %w", name, err)
-	} else if n == 0 {
-		p.Err = errors.New("fail to parse %s")
+	exp = strings.TrimPrefix(exp, "+")
+	if strings.HasPrefix(exp, "-") {
+		exp = "-" + strings.TrimLeft(exp[1:], "0")
+	} else {
+		exp = strings.TrimLeft(exp, "0")
 	}
+	return mantissa + "E" + exp + suffix
 }
@@ -0,0 +1,107 @@
+package class
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity uint8
+
+const (
+	// SeverityWarning is a problem which does not prevent the structure from being parsed,
+	// such as trailing bytes in an attribute.
+	SeverityWarning Severity = iota + 1
+	// SeverityError is an invalid structure which the parser skipped.
+	SeverityError
+	// SeverityFatal is an error which the parser cannot resume after.
+	SeverityFatal
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	}
+	return fmt.Sprintf("Severity(%d)", uint8(s))
+}
+
+// Diagnostic is a problem found while parsing a class file in lenient mode.
+// The embedded ParseError locates the problem by its Path and Offset.
+type Diagnostic struct {
+	Severity Severity
+	*ParseError
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %v", d.Severity, d.ParseError)
+}
+
+// diagnose records the error as a diagnostic, or each of ParseErrors as a diagnostic.
+func (c *ClassFile) diagnose(severity Severity, err error) {
+	if errs, ok := err.(ParseErrors); ok {
+		for _, pe := range errs {
+			c.diagnose(severity, pe)
+		}
+		return
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		pe = &ParseError{Err: err}
+	}
+	c.diagnostics = append(c.diagnostics, Diagnostic{Severity: severity, ParseError: pe})
+}
+
+// tolerate records the error of the reader as a diagnostic and clears it in lenient mode,
+// so that the parser resumes after a problem which does not break the structure being read.
+func (c *ClassFile) tolerate(er *errReader, severity Severity) {
+	if er.err == nil || !c.options.Lenient {
+		return
+	}
+	c.diagnose(severity, er.err)
+	er.err = nil
+}
+
+// parseLenientAttribute reads the info of the attribute by its attribute_length in advance,
+// so that an invalid attribute is kept as RawAttribute and the parser resumes at the next structure.
+func parseLenientAttribute(er *errReader, cf *ClassFile, base *attributeInfoBase, body func(base *attributeInfoBase, er *errReader) attributeInfo) attributeInfo {
+	var info []byte
+	if !item(er, "info", slice(&info, int(base.attributeLength))) {
+		return nil
+	}
+
+	ir := newAttributeErrReader(info, er.offset, er.structPath())
+	attr := body(base, ir)
+	if ir.err != nil {
+		cf.diagnose(SeverityError, ir.err)
+		return &RawAttribute{attributeInfoBase: *base, info: info}
+	}
+	if !item(ir, "info", eof) {
+		cf.diagnose(SeverityWarning, ir.err)
+	}
+	return attr
+}
+
+// trimPartial drops the entries which have not been parsed due to a fatal error,
+// so that the accessors of a partial ClassFile only see the parsed entries.
+func (c *ClassFile) trimPartial() {
+	c.ConstantPool = c.ConstantPool[:parsedCount(c.ConstantPool, func(e Constant) bool { return e != nil })]
+	c.interfaces = c.interfaces[:parsedCount(c.interfaces, func(i uint16) bool { return i != 0 })]
+	c.fields = c.fields[:parsedCount(c.fields, func(f fieldInfo) bool { return f.span.Length != 0 })]
+	c.methods = c.methods[:parsedCount(c.methods, func(m methodInfo) bool { return m.span.Length != 0 })]
+	c.attributes = c.attributes[:parsedCount(c.attributes, func(a attributeInfo) bool { return a != nil })]
+	c.interfaceCount = uint16(len(c.interfaces))
+}
+
+func parsedCount[T any](es []T, parsed func(T) bool) int {
+	for i, e := range es {
+		if !parsed(e) {
+			return i
+		}
+	}
+	return len(es)
+}
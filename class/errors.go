@@ -0,0 +1,89 @@
+package class
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors to classify a ParseError with errors.Is.
+var (
+	// ErrTruncated reports that the class file ends in the middle of an item.
+	ErrTruncated = errors.New("truncated class file")
+	// ErrTrailingBytes reports that extra bytes follow the ClassFile structure or an attribute.
+	ErrTrailingBytes = errors.New("unexpected trailing bytes")
+	// ErrBadMagic reports that the class file does not start with 0xCAFEBABE.
+	ErrBadMagic = errors.New("bad magic number")
+	// ErrBadConstantPoolReference reports an index which does not refer to a valid entry
+	// of the expected kind in the constant_pool table.
+	ErrBadConstantPoolReference = errors.New("bad constant pool reference")
+	// ErrUnsupportedVersion reports a class file version which this package does not support.
+	ErrUnsupportedVersion = errors.New("unsupported class file version")
+	// ErrAttributeOverrun reports that an attribute reads beyond its attribute_length.
+	ErrAttributeOverrun = errors.New("attribute overruns its attribute_length")
+	// ErrLimitExceeded reports that a class file exceeds one of the Limits.
+	ErrLimitExceeded = errors.New("parse limit exceeded")
+	// ErrMalformedUTF8 reports bytes which are not in the modified UTF-8 of a CONSTANT_Utf8_info structure.
+	ErrMalformedUTF8 = errors.New("malformed modified UTF-8")
+	// ErrInvalidAccessFlags reports a combination of access flags which the JVM specification forbids.
+	ErrInvalidAccessFlags = errors.New("invalid access flags")
+)
+
+// ParseError is an error while parsing a class file.
+type ParseError struct {
+	// Offset is the byte offset of the item from the beginning of the class file.
+	Offset int64
+	// Path is the path of the item, such as "methods[1].attributes[0].code_length".
+	Path string
+	// Expected and Actual are the expected and actual values of the item,
+	// or empty if the error is not about the value.
+	Expected string
+	Actual   string
+	// Err is the cause, which may wrap one of the sentinel errors such as ErrTruncated.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("fail to parse %s at offset %d: %v", e.Path, e.Offset, e.Err)
+	if e.Expected != "" || e.Actual != "" {
+		msg += fmt.Sprintf(" (got: %s, want: %s)", e.Actual, e.Expected)
+	}
+	return msg
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ParseErrors is a list of errors found together, such as all the invalid references in the constant_pool table.
+// errors.Is and errors.As report whether any of them matches.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, pe := range e {
+		msgs[i] = pe.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e ParseErrors) Is(target error) bool {
+	for _, pe := range e {
+		if errors.Is(pe, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ParseErrors) As(target any) bool {
+	for _, pe := range e {
+		if errors.As(pe, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueError returns a ParseError about the value of the current item, whose path and offset are filled by errReader.
+func valueError(err error, expected string, actual any) *ParseError {
+	return &ParseError{Expected: expected, Actual: fmt.Sprint(actual), Err: err}
+}
@@ -1,33 +1,178 @@
 package class
 
+import (
+	"github.com/thara/godiva/descriptor"
+	"github.com/thara/godiva/signature"
+)
+
 type fieldInfo struct {
-	accessFlag      AccessFlags
+	accessFlags     FieldAccessFlags
 	nameIndex       uint16
 	descriptorIndex uint16
 	attributesCount uint16
 	attributes      []attributeInfo
+
+	span Span
 }
 
 func parseField(er *errReader, cf *ClassFile) fieldInfo {
 	var f fieldInfo
+	offset := er.r.n
 
-	var accessFlag uint16
-	if item(er, "access_flags", integer(&accessFlag)) {
-		f.accessFlag = AccessFlags(accessFlag)
+	if item(er, "access_flags", integer(&f.accessFlags)) {
+		validate(er, f.accessFlags, fieldAccessFlags(cf))
+		cf.tolerate(er, SeverityError)
 	}
 
-	if item(er, "name_index", integer(&f.nameIndex, constantPoolStructure[uint16, *constantUtf8](cf))) {
-		//TODO must a valid unqualified name
-	}
-	if item(er, "descriptor_index", integer(&f.descriptorIndex, constantPoolStructure[uint16, *constantUtf8](cf))) {
-		// must a valid field descriptor
-	}
+	item(er, "name_index", integer(&f.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), unqualifiedName(cf)))
+	item(er, "descriptor_index", integer(&f.descriptorIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), fieldDescriptor(cf)))
 
 	if item(er, "attributes_count", integer(&f.attributesCount)) {
-		f.attributes = make([]attributeInfo, f.attributesCount)
+		f.attributes = makeTable[attributeInfo](er, cf, int(f.attributesCount))
 		item(er, "attributes", entries(f.attributes, func(er *errReader) attributeInfo {
 			return parseFieldAttributeInfo(er, cf)
 		}))
 	}
+
+	// a ConstantValue attribute of a non-static field is ignored
+	if er.err == nil && f.accessFlags&FieldAccessFlagsStatic != 0 {
+		if attr, ok := findAttribute[*attributeConstantValue](f.attributes); ok {
+			// the constant is checked with the descriptor, and reported at the constantvalue_index after the attribute header
+			item(er, "ConstantValue_attribute's constantvalue_index", func(e *errReader) bool {
+				e.offset = attr.span.Offset + 6
+				validate(e, attr.constantValueIndex, constantValueType(cf, f.descriptorIndex))
+				return e.err == nil
+			})
+			cf.tolerate(er, SeverityError)
+		}
+	}
+	f.span = spanFrom(er, offset)
 	return f
 }
+
+// Field is a field declared by a class or interface.
+type Field struct {
+	cf   *ClassFile
+	info *fieldInfo
+}
+
+// Name returns the name of the field.
+func (f *Field) Name() string {
+	return f.cf.utf8(f.info.nameIndex)
+}
+
+// Descriptor returns the field descriptor, such as "Ljava/lang/String;".
+func (f *Field) Descriptor() string {
+	return f.cf.utf8(f.info.descriptorIndex)
+}
+
+// Type returns the type of the field parsed from its descriptor.
+// ok is false if the descriptor is invalid.
+func (f *Field) Type() (t descriptor.Type, ok bool) {
+	t, err := descriptor.ParseField(f.Descriptor())
+	if err != nil {
+		return nil, false
+	}
+	return t, true
+}
+
+// Signature returns the generic type of the field from its Signature attribute.
+// ok is false if the field has no Signature attribute,
+// or if the signature is invalid, which a ClassFile parsed in lenient mode can have.
+func (f *Field) Signature() (sig signature.Type, ok bool) {
+	attr, ok := findAttribute[*attributeSignature](f.info.attributes)
+	if !ok {
+		return nil, false
+	}
+	sig, err := signature.ParseField(f.cf.utf8(attr.signatureIndex))
+	if err != nil {
+		return nil, false
+	}
+	return sig, true
+}
+
+// ConstantValue returns the value of the ConstantValue attribute of the field,
+// narrowed to the type of the field: int8 for byte, uint16 for char, int16 for short, bool for boolean,
+// and int32, float32, int64, float64 or string for the other types.
+// ok is false if the field has no ConstantValue attribute or is not static, in which case the attribute is ignored,
+// or if the constant does not match the type of the field, which is tolerated only in lenient mode.
+func (f *Field) ConstantValue() (value any, ok bool) {
+	attr, ok := findAttribute[*attributeConstantValue](f.info.attributes)
+	if !ok || f.info.accessFlags&FieldAccessFlagsStatic == 0 || constantValueType(f.cf, f.info.descriptorIndex).validate(attr.constantValueIndex, "constantvalue_index") != nil {
+		return nil, false
+	}
+
+	c, _ := f.cf.lookupConstantPool(attr.constantValueIndex)
+	switch c := c.(type) {
+	case *ConstantInteger:
+		v := c.Int32()
+		switch f.Descriptor() {
+		case "B":
+			return int8(v), true
+		case "C":
+			return uint16(v), true
+		case "S":
+			return int16(v), true
+		case "Z":
+			return booleanValue(v), true
+		}
+		return v, true
+	case *ConstantFloat:
+		return c.Float32(), true
+	case *ConstantLong:
+		return c.Int64(), true
+	case *ConstantDouble:
+		return c.Float64(), true
+	case *ConstantString:
+		return c.Value(), true
+	}
+	return nil, false
+}
+
+// Span returns the bytes of the field_info structure.
+func (f *Field) Span() Span {
+	return f.info.span
+}
+
+// AccessFlags returns the access_flags item of the field_info structure.
+func (f *Field) AccessFlags() FieldAccessFlags {
+	return f.info.accessFlags
+}
+
+// Attributes returns the attributes of the field in the order they appear in the class file.
+func (f *Field) Attributes() []Attribute {
+	attrs := make([]Attribute, len(f.info.attributes))
+	for i, a := range f.info.attributes {
+		attrs[i] = resolveAttribute(a)
+	}
+	return attrs
+}
+
+// Decode decodes the attributes of the field whose decoding is deferred by ParseBytes or ParseReaderAt,
+// and returns the first error.
+func (f *Field) Decode() error {
+	return decodeAttributes(f.info.attributes)
+}
+
+// TypeAnnotations returns the type annotations on the type of the field.
+func (f *Field) TypeAnnotations() []TypeAnnotation {
+	return typeAnnotations(f.cf, f.info.attributes)
+}
+
+// Annotations returns the annotations on the field declaration, both visible and invisible at run time.
+func (f *Field) Annotations() []Annotation {
+	return annotations(f.cf, f.info.attributes)
+}
+
+// HasAnnotation reports whether the field is annotated by the annotation interface of the field descriptor,
+// such as "Ljava/lang/Deprecated;".
+func (f *Field) HasAnnotation(typ string) bool {
+	_, ok := findAnnotation(f.cf, f.info.attributes, typ)
+	return ok
+}
+
+// AnnotationValue returns the value of the element of the name in the annotation of the type on the field.
+// It reports false if the element is not given explicitly.
+func (f *Field) AnnotationValue(typ, name string) (v any, ok bool) {
+	return annotationValue(f.cf, f.info.attributes, typ, name)
+}
@@ -0,0 +1,129 @@
+package class
+
+import "sync"
+
+// lazyAttributes are the attributes which are decoded on first access when the class file is parsed
+// by ParseBytes or ParseReaderAt, since they take up most of a class file while many callers never look into them.
+// The value is the type of the decoded attribute.
+var lazyAttributes = map[string]attributeInfo{
+	"Code":                                 (*attributeCode)(nil),
+	"RuntimeVisibleAnnotations":            (*attributeRuntimeVisibleAnnotations)(nil),
+	"RuntimeInvisibleAnnotations":          (*attributeRuntimeInvisibleAnnotations)(nil),
+	"RuntimeVisibleParameterAnnotations":   (*attributeRuntimeVisibleParameterAnnotations)(nil),
+	"RuntimeInvisibleParameterAnnotations": (*attributeRuntimeInvisibleParameterAnnotations)(nil),
+	"RuntimeVisibleTypeAnnotations":        (*attributeRuntimeVisibleTypeAnnotations)(nil),
+	"RuntimeInvisibleTypeAnnotations":      (*attributeRuntimeInvisibleTypeAnnotations)(nil),
+	"AnnotationDefault":                    (*attributeAnnotationDefault)(nil),
+}
+
+// lazyAttribute is an attribute whose info is decoded on first access.
+// An attribute which fails to decode is treated as an unrecognized attribute,
+// and the error is reported by Method.Decode, Field.Decode or ClassFile.DecodeAll.
+type lazyAttribute struct {
+	attributeInfoBase
+	kind attributeInfo
+	path pathSnapshot
+	info *offsetReader
+
+	once   sync.Once
+	decode func(base *attributeInfoBase, er *errReader) attributeInfo
+	attr   attributeInfo
+	err    error
+}
+
+// parseLazyAttribute skips the info of the attribute to decode it on first access by the body.
+// It reports false if the input cannot be read again later.
+func parseLazyAttribute(er *errReader, base *attributeInfoBase, kind attributeInfo, body func(base *attributeInfoBase, er *errReader) attributeInfo) (attributeInfo, bool) {
+	if er.r.at == nil {
+		return nil, false
+	}
+	a := &lazyAttribute{attributeInfoBase: *base, kind: kind, decode: body}
+	if !item(er, "info", func(e *errReader) bool {
+		if err := e.r.check(int64(base.attributeLength)); err != nil {
+			e.fail(readError(err))
+			return false
+		}
+		a.info = e.r.sub(int64(base.attributeLength), ErrAttributeOverrun)
+		return true
+	}) {
+		return nil, true
+	}
+	a.path = er.snapshot()
+	return a, true
+}
+
+func (a *lazyAttribute) resolve() (attributeInfo, error) {
+	a.once.Do(func() {
+		r := *a.info
+		er := &errReader{r: &r, path: a.path.String(), offset: r.n}
+		attr := a.decode(&a.attributeInfoBase, er)
+		item(er, "info", eof)
+		if er.err != nil {
+			r = *a.info
+			info, _ := r.next(int(a.attributeLength), nil)
+			attr, a.err = &RawAttribute{attributeInfoBase: a.attributeInfoBase, info: info}, er.err
+		}
+		a.attr, a.decode = attr, nil
+	})
+	return a.attr, a.err
+}
+
+// resolveAttribute returns the decoded attribute if the attribute is decoded lazily.
+func resolveAttribute(a attributeInfo) attributeInfo {
+	if l, ok := a.(*lazyAttribute); ok {
+		a, _ = l.resolve()
+	}
+	return a
+}
+
+// resolveAttributeNamed returns the decoded attribute if it has one of the names, and nil otherwise
+// without decoding it, so that looking for some attributes leaves the others such as Code undecoded.
+func resolveAttributeNamed(a attributeInfo, names ...string) attributeInfo {
+	for _, name := range names {
+		if a.Name() == name {
+			return resolveAttribute(a)
+		}
+	}
+	return nil
+}
+
+// DecodeAll decodes all the attributes whose decoding is deferred by ParseBytes or ParseReaderAt,
+// and returns the first error. The attributes which fail to decode are treated as unrecognized attributes.
+func (c *ClassFile) DecodeAll() error {
+	err := decodeAttributes(c.attributes)
+	for _, f := range c.Fields() {
+		if e := f.Decode(); err == nil {
+			err = e
+		}
+	}
+	for _, m := range c.Methods() {
+		if e := m.Decode(); err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+func decodeAttributes(attrs []attributeInfo) (err error) {
+	for _, a := range attrs {
+		if l, ok := a.(*lazyAttribute); ok {
+			var e error
+			if a, e = l.resolve(); err == nil {
+				err = e
+			}
+		}
+		switch attr := a.(type) {
+		case *attributeCode:
+			if e := decodeAttributes(attr.attributes); err == nil {
+				err = e
+			}
+		case *attributeRecord:
+			for _, c := range attr.components {
+				if e := decodeAttributes(c.attributes); err == nil {
+					err = e
+				}
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+package class
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thara/godiva/descriptor"
+	"github.com/thara/godiva/signature"
+)
+
+type methodInfo struct {
+	accessFlags     MethodAccessFlags
+	nameIndex       uint16
+	descriptorIndex uint16
+	attributesCount uint16
+	attributes      []attributeInfo
+
+	span Span
+}
+
+func parseMethod(er *errReader, cf *ClassFile) methodInfo {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.6
+	var m methodInfo
+	offset := er.r.n
+
+	item(er, "access_flags", integer(&m.accessFlags))
+
+	if item(er, "name_index", integer(&m.nameIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), methodName(cf))) {
+		// the access_flags are checked with the name of the method, and reported at their own position
+		item(er, "access_flags", func(e *errReader) bool {
+			e.offset = offset
+			validate(e, m.accessFlags, methodAccessFlags(cf, cf.utf8(m.nameIndex)))
+			return e.err == nil
+		})
+		cf.tolerate(er, SeverityError)
+	}
+	static := m.accessFlags&MethodAccessFlagsStatic != 0
+	item(er, "descriptor_index", integer(&m.descriptorIndex, constantPoolStructure[uint16, *ConstantUtf8](cf), methodDescriptor(cf, static)))
+
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-2.html#jvms-2.9.1
+	// an instance initialization method must return void
+	if er.err == nil && cf.utf8(m.nameIndex) == "<init>" {
+		if !strings.HasSuffix(cf.utf8(m.descriptorIndex), ")V") {
+			er.fail(fmt.Errorf("descriptor_index of <init> must be a method descriptor returning void"))
+			cf.tolerate(er, SeverityError)
+		}
+	}
+
+	if item(er, "attributes_count", integer(&m.attributesCount)) {
+		m.attributes = makeTable[attributeInfo](er, cf, int(m.attributesCount))
+		item(er, "attributes", entries(m.attributes, func(er *errReader) attributeInfo {
+			return parseMethodAttributeInfo(er, cf)
+		}))
+	}
+	m.span = spanFrom(er, offset)
+	return m
+}
+
+// Method is a method declared by a class or interface.
+type Method struct {
+	cf   *ClassFile
+	info *methodInfo
+}
+
+// Name returns the name of the method, such as "main" or "<init>".
+func (m *Method) Name() string {
+	return m.cf.utf8(m.info.nameIndex)
+}
+
+// Descriptor returns the method descriptor, such as "([Ljava/lang/String;)V".
+func (m *Method) Descriptor() string {
+	return m.cf.utf8(m.info.descriptorIndex)
+}
+
+// Type returns the parameter types and the return type of the method parsed from its descriptor.
+// ok is false if the descriptor is invalid.
+func (m *Method) Type() (t *descriptor.Method, ok bool) {
+	t, err := descriptor.ParseMethod(m.Descriptor())
+	if err != nil {
+		return nil, false
+	}
+	return t, true
+}
+
+// Signature returns the generic declaration of the method from its Signature attribute.
+// ok is false if the method has no Signature attribute,
+// or if the signature is invalid, which a ClassFile parsed in lenient mode can have.
+func (m *Method) Signature() (sig *signature.MethodSignature, ok bool) {
+	attr, ok := findAttribute[*attributeSignature](m.info.attributes)
+	if !ok {
+		return nil, false
+	}
+	sig, err := signature.ParseMethod(m.cf.utf8(attr.signatureIndex))
+	if err != nil {
+		return nil, false
+	}
+	return sig, true
+}
+
+// Span returns the bytes of the method_info structure.
+func (m *Method) Span() Span {
+	return m.info.span
+}
+
+// AccessFlags returns the access_flags item of the method_info structure.
+func (m *Method) AccessFlags() MethodAccessFlags {
+	return m.info.accessFlags
+}
+
+// Attributes returns the attributes of the method in the order they appear in the class file.
+func (m *Method) Attributes() []Attribute {
+	attrs := make([]Attribute, len(m.info.attributes))
+	for i, a := range m.info.attributes {
+		attrs[i] = resolveAttribute(a)
+	}
+	return attrs
+}
+
+// MethodParameter is a formal parameter recorded in the MethodParameters attribute.
+type MethodParameter struct {
+	// Name is an empty string if the parameter has no name.
+	Name        string
+	AccessFlags ParameterAccessFlags
+}
+
+// Parameters returns the formal parameters of the method from the MethodParameters attribute,
+// or nil if the method has no MethodParameters attribute.
+func (m *Method) Parameters() []MethodParameter {
+	attr, ok := findAttribute[*attributeMethodParameters](m.info.attributes)
+	if !ok {
+		return nil
+	}
+	params := make([]MethodParameter, len(attr.parameters))
+	for i, p := range attr.parameters {
+		if p.nameIndex != 0 {
+			params[i].Name = m.cf.utf8(p.nameIndex)
+		}
+		params[i].AccessFlags = p.accessFlags
+	}
+	return params
+}
+
+// Code returns the Code attribute of the method, or nil if the method is native or abstract.
+// On a class file parsed by ParseBytes or ParseReaderAt, it is also nil if the Code attribute fails to decode,
+// which Decode reports.
+func (m *Method) Code() *Code {
+	attr, ok := findAttribute[*attributeCode](m.info.attributes)
+	if !ok {
+		return nil
+	}
+	return &Code{cf: m.cf, attr: attr}
+}
+
+// Decode decodes the attributes of the method whose decoding is deferred by ParseBytes or ParseReaderAt,
+// and returns the first error.
+func (m *Method) Decode() error {
+	return decodeAttributes(m.info.attributes)
+}
+
+// TypeAnnotations returns the type annotations in the method declaration,
+// such as on its type parameters, return type or throws clause.
+func (m *Method) TypeAnnotations() []TypeAnnotation {
+	return typeAnnotations(m.cf, m.info.attributes)
+}
+
+// Annotations returns the annotations on the method declaration, both visible and invisible at run time.
+func (m *Method) Annotations() []Annotation {
+	return annotations(m.cf, m.info.attributes)
+}
+
+// HasAnnotation reports whether the method is annotated by the annotation interface of the field descriptor,
+// such as "Ljava/lang/Deprecated;".
+func (m *Method) HasAnnotation(typ string) bool {
+	_, ok := findAnnotation(m.cf, m.info.attributes, typ)
+	return ok
+}
+
+// AnnotationValue returns the value of the element of the name in the annotation of the type on the method.
+// It reports false if the element is not given explicitly.
+func (m *Method) AnnotationValue(typ, name string) (v any, ok bool) {
+	return annotationValue(m.cf, m.info.attributes, typ, name)
+}
+
+// ParameterAnnotations returns the annotations on each formal parameter of the method,
+// merging the RuntimeVisibleParameterAnnotations and RuntimeInvisibleParameterAnnotations attributes.
+// The parameters may be fewer than in the method descriptor, such as for the implicit parameters of an inner class constructor.
+func (m *Method) ParameterAnnotations() [][]Annotation {
+	var params [][]Annotation
+	merge := func(ps []parameterAnnotation, visible bool) {
+		for len(params) < len(ps) {
+			params = append(params, nil)
+		}
+		for i, p := range ps {
+			for _, a := range p.annotations {
+				params[i] = append(params[i], resolveAnnotation(m.cf, a, visible))
+			}
+		}
+	}
+	for _, a := range m.info.attributes {
+		switch attr := resolveAttributeNamed(a, "RuntimeVisibleParameterAnnotations", "RuntimeInvisibleParameterAnnotations").(type) {
+		case *attributeRuntimeVisibleParameterAnnotations:
+			merge(attr.parameterAnnotations, true)
+		case *attributeRuntimeInvisibleParameterAnnotations:
+			merge(attr.parameterAnnotations, false)
+		}
+	}
+	return params
+}
+
+// AnnotationDefault returns the default value of the element which the method of an annotation interface represents,
+// in the same Go type as AnnotationElement.Value.
+func (m *Method) AnnotationDefault() (v any, ok bool) {
+	attr, ok := findAttribute[*attributeAnnotationDefault](m.info.attributes)
+	if !ok {
+		return nil, false
+	}
+	return resolveElementValue(m.cf, attr.defaultValue, true), true
+}
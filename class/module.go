@@ -0,0 +1,284 @@
+package class
+
+import "fmt"
+
+type moduleRequires struct {
+	requiresIndex        uint16
+	requiresFlags        RequiresFlags
+	requiresVersionIndex uint16
+}
+
+type moduleExports struct {
+	exportsIndex   uint16
+	exportsFlags   ExportsFlags
+	exportsToCount uint16
+	exportsToIndex []uint16
+}
+
+type moduleOpens struct {
+	opensIndex   uint16
+	opensFlags   ExportsFlags
+	opensToCount uint16
+	opensToIndex []uint16
+}
+
+type moduleProvides struct {
+	providesIndex     uint16
+	providesWithCount uint16
+	providesWithIndex []uint16
+}
+
+type attributeModule struct {
+	attributeInfoBase
+	moduleNameIndex    uint16
+	moduleFlags        ModuleFlags
+	moduleVersionIndex uint16
+
+	requiresCount uint16
+	requires      []moduleRequires
+	exportsCount  uint16
+	exports       []moduleExports
+	opensCount    uint16
+	opens         []moduleOpens
+	usesCount     uint16
+	usesIndex     []uint16
+	providesCount uint16
+	provides      []moduleProvides
+}
+
+func (base *attributeInfoBase) module(er *errReader, cf *ClassFile) *attributeModule {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.25
+	attr := attributeModule{attributeInfoBase: *base}
+	item(er, "module_name_index", integer(&attr.moduleNameIndex, constantPoolStructure[uint16, *ConstantModule](cf)))
+	item(er, "module_flags", integer(&attr.moduleFlags))
+	optionalUtf8Index(er, cf, "module_version_index", &attr.moduleVersionIndex)
+
+	if item(er, "requires_count", integer(&attr.requiresCount)) {
+		attr.requires = makeTable[moduleRequires](er, cf, int(attr.requiresCount))
+		item(er, "requires", entries(attr.requires, func(er *errReader) moduleRequires {
+			var r moduleRequires
+			item(er, "requires_index", integer(&r.requiresIndex, constantPoolStructure[uint16, *ConstantModule](cf)))
+			item(er, "requires_flags", integer(&r.requiresFlags))
+			optionalUtf8Index(er, cf, "requires_version_index", &r.requiresVersionIndex)
+			return r
+		}))
+	}
+
+	if item(er, "exports_count", integer(&attr.exportsCount)) {
+		attr.exports = makeTable[moduleExports](er, cf, int(attr.exportsCount))
+		item(er, "exports", entries(attr.exports, func(er *errReader) moduleExports {
+			var e moduleExports
+			item(er, "exports_index", integer(&e.exportsIndex, constantPoolStructure[uint16, *ConstantPackage](cf)))
+			item(er, "exports_flags", integer(&e.exportsFlags))
+			if item(er, "exports_to_count", integer(&e.exportsToCount)) {
+				e.exportsToIndex = constantPoolIndexes[*ConstantModule](er, cf, "exports_to_index", e.exportsToCount)
+			}
+			return e
+		}))
+	}
+
+	if item(er, "opens_count", integer(&attr.opensCount)) {
+		attr.opens = makeTable[moduleOpens](er, cf, int(attr.opensCount))
+		item(er, "opens", entries(attr.opens, func(er *errReader) moduleOpens {
+			var o moduleOpens
+			item(er, "opens_index", integer(&o.opensIndex, constantPoolStructure[uint16, *ConstantPackage](cf)))
+			item(er, "opens_flags", integer(&o.opensFlags))
+			if item(er, "opens_to_count", integer(&o.opensToCount)) {
+				o.opensToIndex = constantPoolIndexes[*ConstantModule](er, cf, "opens_to_index", o.opensToCount)
+			}
+			return o
+		}))
+	}
+
+	if item(er, "uses_count", integer(&attr.usesCount)) {
+		attr.usesIndex = constantPoolIndexes[*ConstantClass](er, cf, "uses_index", attr.usesCount)
+	}
+
+	if item(er, "provides_count", integer(&attr.providesCount)) {
+		attr.provides = makeTable[moduleProvides](er, cf, int(attr.providesCount))
+		item(er, "provides", entries(attr.provides, func(er *errReader) moduleProvides {
+			var p moduleProvides
+			item(er, "provides_index", integer(&p.providesIndex, constantPoolStructure[uint16, *ConstantClass](cf)))
+			if item(er, "provides_with_count", integer(&p.providesWithCount, min[uint16](1))) {
+				p.providesWithIndex = constantPoolIndexes[*ConstantClass](er, cf, "provides_with_index", p.providesWithCount)
+			}
+			return p
+		}))
+	}
+	return &attr
+}
+
+type attributeModulePackages struct {
+	attributeInfoBase
+	packageCount uint16
+	packageIndex []uint16
+}
+
+func (base *attributeInfoBase) modulePackages(er *errReader, cf *ClassFile) *attributeModulePackages {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.26
+	attr := attributeModulePackages{attributeInfoBase: *base}
+	if item(er, "package_count", integer(&attr.packageCount)) {
+		attr.packageIndex = constantPoolIndexes[*ConstantPackage](er, cf, "package_index", attr.packageCount)
+	}
+	return &attr
+}
+
+type attributeModuleMainClass struct {
+	attributeInfoBase
+	mainClassIndex uint16
+}
+
+func (base *attributeInfoBase) moduleMainClass(er *errReader, cf *ClassFile) *attributeModuleMainClass {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.27
+	attr := attributeModuleMainClass{attributeInfoBase: *base}
+	if base.attributeLength != 2 {
+		er.fail(fmt.Errorf("invalid attribute length(%d) for ModuleMainClass_attribute", base.attributeLength))
+		return nil
+	}
+	item(er, "main_class_index", integer(&attr.mainClassIndex, constantPoolStructure[uint16, *ConstantClass](cf)))
+	return &attr
+}
+
+// ModuleDescriptor is a module declaration described by the Module, ModulePackages
+// and ModuleMainClass attributes of a module-info.class.
+type ModuleDescriptor struct {
+	Name  string
+	Flags ModuleFlags
+	// Version is an empty string if no version information is present.
+	Version string
+
+	Requires []ModuleRequires
+	Exports  []ModuleExports
+	Opens    []ModuleOpens
+	// Uses are the binary names of the service interfaces.
+	Uses     []string
+	Provides []ModuleProvides
+
+	// Packages are the packages of the module from the ModulePackages attribute.
+	Packages []string
+	// MainClass is the binary name of the main class from the ModuleMainClass attribute,
+	// or an empty string if it is not present.
+	MainClass string
+}
+
+// ModuleRequires is a dependence of the module.
+type ModuleRequires struct {
+	Module string
+	Flags  RequiresFlags
+	// Version is the version of the module when the current module was compiled,
+	// or an empty string if no version information is present.
+	Version string
+}
+
+// ModuleExports is a package exported by the module.
+type ModuleExports struct {
+	Package string
+	Flags   ExportsFlags
+	// To are the modules to which the package is exported, or empty if the export is unqualified.
+	To []string
+}
+
+// ModuleOpens is a package opened by the module.
+type ModuleOpens struct {
+	Package string
+	Flags   ExportsFlags
+	// To are the modules to which the package is opened, or empty if the opening is unqualified.
+	To []string
+}
+
+// ModuleProvides is a service implementation provided by the module.
+type ModuleProvides struct {
+	// Service is the binary name of the service interface.
+	Service string
+	// With are the binary names of the service implementations.
+	With []string
+}
+
+// Module returns the module declaration of a module-info.class,
+// or nil if the class file has no Module attribute.
+func (c *ClassFile) Module() *ModuleDescriptor {
+	attr, ok := findAttribute[*attributeModule](c.attributes)
+	if !ok {
+		return nil
+	}
+
+	m := ModuleDescriptor{
+		Name:    c.moduleName(attr.moduleNameIndex),
+		Flags:   attr.moduleFlags,
+		Version: c.optionalUtf8(attr.moduleVersionIndex),
+	}
+	for _, r := range attr.requires {
+		m.Requires = append(m.Requires, ModuleRequires{
+			Module:  c.moduleName(r.requiresIndex),
+			Flags:   r.requiresFlags,
+			Version: c.optionalUtf8(r.requiresVersionIndex),
+		})
+	}
+	for _, e := range attr.exports {
+		m.Exports = append(m.Exports, ModuleExports{
+			Package: c.packageName(e.exportsIndex),
+			Flags:   e.exportsFlags,
+			To:      c.moduleNames(e.exportsToIndex),
+		})
+	}
+	for _, o := range attr.opens {
+		m.Opens = append(m.Opens, ModuleOpens{
+			Package: c.packageName(o.opensIndex),
+			Flags:   o.opensFlags,
+			To:      c.moduleNames(o.opensToIndex),
+		})
+	}
+	if 0 < len(attr.usesIndex) {
+		m.Uses = c.classNames(attr.usesIndex)
+	}
+	for _, p := range attr.provides {
+		m.Provides = append(m.Provides, ModuleProvides{
+			Service: c.className(p.providesIndex),
+			With:    c.classNames(p.providesWithIndex),
+		})
+	}
+
+	if attr, ok := findAttribute[*attributeModulePackages](c.attributes); ok {
+		for _, idx := range attr.packageIndex {
+			m.Packages = append(m.Packages, c.packageName(idx))
+		}
+	}
+	if attr, ok := findAttribute[*attributeModuleMainClass](c.attributes); ok {
+		m.MainClass = c.className(attr.mainClassIndex)
+	}
+	return &m
+}
+
+func (c *ClassFile) moduleName(i uint16) string {
+	module, err := lookupCpinfo[*ConstantModule](c, i)
+	if err != nil {
+		return ""
+	}
+	return module.Name()
+}
+
+func (c *ClassFile) moduleNames(indexes []uint16) []string {
+	if len(indexes) == 0 {
+		return nil
+	}
+	names := make([]string, len(indexes))
+	for i, idx := range indexes {
+		names[i] = c.moduleName(idx)
+	}
+	return names
+}
+
+func (c *ClassFile) packageName(i uint16) string {
+	pkg, err := lookupCpinfo[*ConstantPackage](c, i)
+	if err != nil {
+		return ""
+	}
+	return pkg.Name()
+}
+
+func (c *ClassFile) optionalUtf8(i uint16) string {
+	if i == 0 {
+		return ""
+	}
+	return c.utf8(i)
+}
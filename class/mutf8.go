@@ -0,0 +1,140 @@
+package class
+
+import (
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4.7
+
+// DecodeModifiedUTF8 decodes the bytes of a CONSTANT_Utf8_info structure into a string.
+//
+// Surrogate pairs are combined into supplementary characters.
+// A surrogate code point which is not a part of a pair is kept in its three-byte form (WTF-8),
+// so that EncodeModifiedUTF8 restores the original bytes.
+func DecodeModifiedUTF8(b []byte) (string, error) {
+	ascii := true
+	for _, c := range b {
+		if c == 0 || 0x80 <= c {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(b), nil
+	}
+
+	buf := make([]byte, 0, len(b))
+	for i := 0; i < len(b); {
+		r, n, err := decodeModifiedUTF8Unit(b[i:])
+		if err != nil {
+			return "", fmt.Errorf("%w at byte %d", err, i)
+		}
+
+		if utf16.IsSurrogate(r) && r < 0xDC00 {
+			if r2, n2, err := decodeModifiedUTF8Unit(b[i+n:]); err == nil && 0xDC00 <= r2 && r2 <= 0xDFFF {
+				buf = utf8.AppendRune(buf, utf16.DecodeRune(r, r2))
+				i += n + n2
+				continue
+			}
+		}
+		if utf16.IsSurrogate(r) {
+			buf = append(buf, b[i:i+n]...)
+		} else {
+			buf = utf8.AppendRune(buf, r)
+		}
+		i += n
+	}
+	return string(buf), nil
+}
+
+// validateModifiedUTF8 returns the error which DecodeModifiedUTF8 returns for the bytes without decoding them.
+func validateModifiedUTF8(b []byte) error {
+	for i := 0; i < len(b); {
+		if c := b[i]; c != 0 && c < 0x80 {
+			i++
+			continue
+		}
+		_, n, err := decodeModifiedUTF8Unit(b[i:])
+		if err != nil {
+			return fmt.Errorf("%w at byte %d", err, i)
+		}
+		i += n
+	}
+	return nil
+}
+
+// decodeModifiedUTF8Unit decodes a UTF-16 code unit at the head of b.
+// A code unit must be in its shortest form, except for the null character in two bytes.
+func decodeModifiedUTF8Unit(b []byte) (rune, int, error) {
+	if len(b) == 0 {
+		return 0, 0, ErrMalformedUTF8
+	}
+
+	switch c := b[0]; {
+	case c == 0:
+		return 0, 0, fmt.Errorf("%w: null byte", ErrMalformedUTF8)
+	case c < 0x80:
+		return rune(c), 1, nil
+	case c&0xE0 == 0xC0:
+		if len(b) < 2 || b[1]&0xC0 != 0x80 {
+			return 0, 0, fmt.Errorf("%w: truncated two-byte sequence", ErrMalformedUTF8)
+		}
+		r := rune(c&0x1F)<<6 | rune(b[1]&0x3F)
+		if r < 0x80 && r != 0 {
+			return 0, 0, fmt.Errorf("%w: overlong two-byte sequence", ErrMalformedUTF8)
+		}
+		return r, 2, nil
+	case c&0xF0 == 0xE0:
+		if len(b) < 3 || b[1]&0xC0 != 0x80 || b[2]&0xC0 != 0x80 {
+			return 0, 0, fmt.Errorf("%w: truncated three-byte sequence", ErrMalformedUTF8)
+		}
+		r := rune(c&0x0F)<<12 | rune(b[1]&0x3F)<<6 | rune(b[2]&0x3F)
+		if r < 0x800 {
+			return 0, 0, fmt.Errorf("%w: overlong three-byte sequence", ErrMalformedUTF8)
+		}
+		return r, 3, nil
+	}
+	return 0, 0, fmt.Errorf("%w: invalid byte 0x%02x", ErrMalformedUTF8, b[0])
+}
+
+// EncodeModifiedUTF8 encodes a string into the bytes of a CONSTANT_Utf8_info structure.
+//
+// The null character is encoded as 0xC0 0x80, and supplementary characters are encoded as surrogate pairs.
+// Surrogate code points in their three-byte form are kept as they are, and the other invalid bytes
+// are encoded as U+FFFD.
+func EncodeModifiedUTF8(s string) []byte {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 && isEncodedSurrogate(s[i:]) {
+			buf = append(buf, s[i:i+3]...)
+			i += 3
+			continue
+		}
+		buf = appendModifiedUTF8(buf, r)
+		i += n
+	}
+	return buf
+}
+
+func appendModifiedUTF8(buf []byte, r rune) []byte {
+	switch {
+	case r == 0:
+		return append(buf, 0xC0, 0x80)
+	case r < 0x80:
+		return append(buf, byte(r))
+	case r < 0x800:
+		return append(buf, 0xC0|byte(r>>6), 0x80|byte(r)&0x3F)
+	case r < 0x10000:
+		return append(buf, 0xE0|byte(r>>12), 0x80|byte(r>>6)&0x3F, 0x80|byte(r)&0x3F)
+	}
+	r1, r2 := utf16.EncodeRune(r)
+	return appendModifiedUTF8(appendModifiedUTF8(buf, r1), r2)
+}
+
+// isEncodedSurrogate reports whether s starts with a surrogate code point in the three-byte form.
+func isEncodedSurrogate(s string) bool {
+	return 3 <= len(s) && s[0] == 0xED && 0xA0 <= s[1] && s[1] <= 0xBF && s[2]&0xC0 == 0x80
+}
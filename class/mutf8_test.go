@@ -0,0 +1,72 @@
+package class_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/thara/godiva/class"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModifiedUTF8(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded []byte
+		decoded string
+	}{
+		{"ascii", []byte("java/lang/Object"), "java/lang/Object"},
+		{"null", []byte{'a', 0xC0, 0x80, 'b'}, "a\x00b"},
+		{"two bytes", []byte{0xC3, 0xA9, 't', 0xC3, 0xA9}, "été"},
+		{"three bytes", []byte{0xE6, 0x97, 0xA5, 0xE6, 0x9C, 0xAC}, "日本"},
+		{"supplementary", []byte{0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80}, "😀"},
+		{"lone surrogate", []byte{'x', 0xED, 0xB8, 0x80, 'y'}, "x\xED\xB8\x80y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := DecodeModifiedUTF8(tt.encoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.decoded, s)
+			assert.Equal(t, tt.encoded, EncodeModifiedUTF8(s))
+		})
+	}
+
+	for _, b := range [][]byte{
+		{'a', 0x00},
+		{0xC3},
+		{0xE6, 0x97},
+		{0xE6, 0x97, 0x25},
+		{0xF0, 0x9F, 0x98, 0x80},
+		{0x80},
+		// overlong forms other than the null character
+		{0xC1, 0x81},
+		{0xC0, 0xAF},
+		{0xE0, 0x80, 0x80},
+		{0xE0, 0x9F, 0xBF},
+	} {
+		_, err := DecodeModifiedUTF8(b)
+		assert.ErrorIs(t, err, ErrMalformedUTF8, "%x", b)
+	}
+
+	assert.Equal(t, []byte{0xEF, 0xBF, 0xBD}, EncodeModifiedUTF8("\xFF"))
+}
+
+func TestParseModifiedUTF8(t *testing.T) {
+	b := newClassBuilder("Sample", "java/lang/Object")
+	b.attributes = [][]byte{b.attribute("SourceFile", u2(b.utf8(string(EncodeModifiedUTF8("Ünïcödé😀.java")))))}
+
+	cf, err := Parse(bytes.NewReader(b.build()))
+	require.NoError(t, err)
+	assert.Equal(t, "Ünïcödé😀.java", cf.SourceFile())
+
+	b = newClassBuilder("Sample", "java/lang/Object")
+	b.attributes = [][]byte{b.attribute("SourceFile", u2(b.utf8("bad\xF0.java")))}
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.ErrorIs(t, err, ErrMalformedUTF8)
+
+	b = newClassBuilder("Sample", "java/lang/Object")
+	b.attributes = [][]byte{b.attribute("SourceFile", u2(b.utf8("a\xC0\xAFb.java")))}
+	_, err = Parse(bytes.NewReader(b.build()))
+	assert.ErrorIs(t, err, ErrMalformedUTF8)
+}
@@ -5,34 +5,271 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"unsafe"
 
 	"golang.org/x/exp/constraints"
 )
 
 type errReader struct {
-	r   io.Reader
+	r   *offsetReader
 	err error
 
+	// path is the path of the structure being read, such as "methods[1].attributes[0]".
+	// The path of an entry read by readEntries is built from its parent and index only when it is needed.
+	path   string
+	parent *errReader
+	index  int
+
 	name string
+	// offset is the byte offset of the current item
+	offset int64
+
+	// depth is the nesting depth of element_value structures being read
+	depth int
 }
 
-func item(e *errReader, name string, f func(e *errReader) bool) bool {
-	if e.err != nil {
-		return false
+func newErrReader(r io.Reader, offset int64, path string) *errReader {
+	return &errReader{r: &offsetReader{r: r, n: offset}, path: path, offset: offset}
+}
+
+// newBytesErrReader returns a reader of the bytes which start at the offset in the class file.
+func newBytesErrReader(data []byte, offset int64, path string) *errReader {
+	return newReaderAtErrReader(byteSource(data), int64(len(data)), offset, path)
+}
+
+// newReaderAtErrReader returns a reader of the size bytes in r which start at the offset in the class file.
+func newReaderAtErrReader(r io.ReaderAt, size, offset int64, path string) *errReader {
+	return &errReader{r: &offsetReader{at: r, n: offset, base: offset, end: offset + size, bounded: true}, path: path, offset: offset}
+}
+
+// newAttributeErrReader returns a reader of the info bytes of an attribute which start at the offset in the class file.
+func newAttributeErrReader(info []byte, offset int64, path string) *errReader {
+	er := newBytesErrReader(info, offset, path)
+	er.r.overrun = ErrAttributeOverrun
+	return er
+}
+
+// offsetReader counts the bytes read from the beginning of the class file.
+//
+// When the input is an io.ReaderAt, it reads the input at the offset directly,
+// so that a range of bytes can be skipped without reading and decoded later.
+// A byteSource is further sliced without copying.
+type offsetReader struct {
+	r io.Reader
+	n int64
+
+	// at is the input from base in the class file, which is used instead of r if not nil
+	at   io.ReaderAt
+	base int64
+
+	// end is the offset where the input or the structure being read ends if bounded,
+	// and overrun is the error for reading beyond the end of the structure
+	end     int64
+	bounded bool
+	overrun error
+
+	// limit is the offset which the class file must not go beyond, or 0 for no limit
+	limit int64
+
+	buf [8]byte
+}
+
+func (r *offsetReader) Read(p []byte) (int, error) {
+	if r.bounded {
+		if r.end <= r.n {
+			if r.overrun != nil {
+				return 0, r.overrun
+			}
+			return 0, io.EOF
+		}
+		if rest := r.end - r.n; rest < int64(len(p)) {
+			p = p[:rest]
+		}
+	} else if r.limit != 0 {
+		if r.limit <= r.n {
+			// the class file must end here
+			if n, _ := io.ReadFull(r.r, r.buf[:1]); n != 0 {
+				return 0, r.tooLarge()
+			}
+			return 0, io.EOF
+		}
+		if rest := r.limit - r.n; rest < int64(len(p)) {
+			p = p[:rest]
+		}
 	}
-	e.name = name
-	return f(e)
+
+	if r.at == nil {
+		n, err := r.r.Read(p)
+		r.n += int64(n)
+		return n, err
+	}
+	n, err := r.at.ReadAt(p, r.n-r.base)
+	r.n += int64(n)
+	if n == len(p) && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// check returns the error for the next size bytes if they are known to be beyond the end or the limit,
+// so that a hostile length or count does not make the parser allocate before the input runs out.
+func (r *offsetReader) check(size int64) error {
+	if r.bounded && r.end-r.n < size {
+		if r.overrun != nil {
+			return r.overrun
+		}
+		return io.ErrUnexpectedEOF
+	}
+	if r.limit != 0 && r.limit-r.n < size {
+		return r.tooLarge()
+	}
+	return nil
 }
 
-func parent(e *errReader, parent string) *errReader {
-	return &errReader{r: e.r, err: e.err, name: parent}
+func (r *offsetReader) tooLarge() error {
+	return fmt.Errorf("%w: class file is larger than %d bytes", ErrLimitExceeded, r.limit)
 }
 
-func child(e *errReader, fieldName string, f func(e *errReader) bool) bool {
+// next returns the next size bytes, which share the memory with the input if it is a byteSource.
+// The bytes in buf are valid until the next call.
+func (r *offsetReader) next(size int, buf []byte) ([]byte, error) {
+	if err := r.check(int64(size)); err != nil {
+		return nil, err
+	}
+	if bs, ok := r.at.(byteSource); ok {
+		i := r.n - r.base
+		r.n += int64(size)
+		return bs[i : i+int64(size) : i+int64(size)], nil
+	}
+	if buf == nil {
+		buf = make([]byte, size)
+	}
+	_, err := io.ReadFull(r, buf[:size])
+	return buf[:size], err
+}
+
+// sub returns a reader of the next size bytes, which fails with the overrun error when it reads beyond them.
+// The bytes are skipped if the input is an io.ReaderAt, and otherwise they are read through the reader.
+// The caller must check the size in advance.
+func (r *offsetReader) sub(size int64, overrun error) *offsetReader {
+	s := &offsetReader{n: r.n, end: r.n + size, bounded: true, overrun: overrun, limit: r.limit}
+	if r.at == nil {
+		s.r = r
+		return s
+	}
+	s.at, s.base = r.at, r.base
+	r.n += size
+	return s
+}
+
+// byteSource is the input given as a byte slice.
+type byteSource []byte
+
+func (b byteSource) ReadAt(p []byte, off int64) (int, error) {
+	if int64(len(b)) <= off {
+		return 0, io.EOF
+	}
+	n := copy(p, b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// structPath returns the path of the structure being read.
+func (e *errReader) structPath() string {
+	if e.parent == nil {
+		return e.path
+	}
+	return e.parent.itemPath() + "[" + strconv.Itoa(e.index) + "]"
+}
+
+// itemPath returns the path of the current item, such as "methods[1].attributes[0].code_length".
+func (e *errReader) itemPath() string {
+	return joinPath(e.structPath(), e.name)
+}
+
+func joinPath(path, name string) string {
+	switch {
+	case path == "":
+		return name
+	case name == "":
+		return path
+	}
+	return path + "." + name
+}
+
+// pathSnapshot is the path of a structure kept in pieces, which outlives the readers reused for the next entries
+// and builds the string only when it is needed.
+type pathSnapshot struct {
+	root  string
+	steps [4]struct {
+		name  string
+		index int
+	}
+	n int
+}
+
+// snapshot returns the path of the structure being read.
+func (e *errReader) snapshot() pathSnapshot {
+	var s pathSnapshot
+	r := e
+	for ; r.parent != nil; r = r.parent {
+		if s.n == len(s.steps) {
+			// too deep to keep in pieces
+			return pathSnapshot{root: e.structPath()}
+		}
+		s.steps[s.n].name, s.steps[s.n].index = r.parent.name, r.index
+		s.n++
+	}
+	s.root = r.path
+	return s
+}
+
+func (s *pathSnapshot) String() string {
+	path := s.root
+	for i := s.n - 1; 0 <= i; i-- {
+		path = joinPath(path, s.steps[i].name) + "[" + strconv.Itoa(s.steps[i].index) + "]"
+	}
+	return path
+}
+
+// reset makes the reader read the entry at the index of the current item of the parent.
+// The reader is reused for all the entries, whose path is built only when it is needed.
+func (e *errReader) reset(parent *errReader, index int) {
+	*e = errReader{r: parent.r, parent: parent, index: index, offset: parent.r.n, depth: parent.depth}
+}
+
+// fail records the error as a ParseError of the current item unless an error has already occurred.
+func (e *errReader) fail(err error) {
+	if e.err != nil {
+		return
+	}
+	if errs, ok := err.(ParseErrors); ok {
+		// each error already has its position
+		e.err = errs
+		return
+	}
+	if pe, ok := err.(*ParseError); ok {
+		// a ParseError from a validator has no position yet
+		if pe.Path == "" {
+			pe.Path = e.itemPath()
+			pe.Offset = e.offset
+		}
+		e.err = pe
+		return
+	}
+	e.err = &ParseError{Offset: e.offset, Path: e.itemPath(), Err: err}
+}
+
+func item(e *errReader, name string, f func(e *errReader) bool) bool {
 	if e.err != nil {
 		return false
 	}
-	return f(&errReader{r: e.r, err: e.err, name: fmt.Sprintf("%s.%s", e.name, fieldName)})
+	e.name = name
+	e.offset = e.r.n
+	return f(e)
 }
 
 func integer[T constraints.Integer](data *T, vs ...validator[T]) func(e *errReader) bool {
@@ -45,14 +282,25 @@ func readInteger[T constraints.Integer](e *errReader, data *T, vs ...validator[T
 	if e.err != nil {
 		return false
 	}
-	if err := binary.Read(e.r, binary.BigEndian, data); err != nil {
-		e.err = fmt.Errorf("fail to parse %s: %w", e.name, err)
+	b, err := e.r.next(int(unsafe.Sizeof(*data)), e.r.buf[:])
+	if err != nil {
+		e.fail(readError(err))
 		return false
 	}
+	switch len(b) {
+	case 1:
+		*data = T(b[0])
+	case 2:
+		*data = T(binary.BigEndian.Uint16(b))
+	case 4:
+		*data = T(binary.BigEndian.Uint32(b))
+	default:
+		*data = T(binary.BigEndian.Uint64(b))
+	}
 
 	for _, v := range vs {
 		if err := v.validate(*data, e.name); err != nil {
-			e.err = err
+			e.fail(err)
 			return false
 		}
 	}
@@ -69,23 +317,83 @@ func readBytes(e *errReader, bytes []byte, vs ...validator[[]byte]) bool {
 	if e.err != nil {
 		return false
 	}
-	if n, err := e.r.Read(bytes); err != nil {
-		e.err = fmt.Errorf("fail to parse %s: %w", e.name, err)
-		return false
-	} else if n == 0 {
-		e.err = errors.New("fail to parse %s")
+	if _, err := io.ReadFull(e.r, bytes); err != nil {
+		e.fail(readError(err))
 		return false
 	}
 
 	for _, v := range vs {
 		if err := v.validate(bytes, e.name); err != nil {
-			e.err = err
+			e.fail(err)
+			return false
+		}
+	}
+	return true
+}
+
+// slice reads the next size bytes into data, which share the memory with the input if possible.
+func slice(data *[]byte, size int) func(e *errReader) bool {
+	return func(e *errReader) bool {
+		if e.err != nil {
+			return false
+		}
+		b, err := e.r.next(size, nil)
+		if err != nil {
+			e.fail(readError(err))
 			return false
 		}
+		*data = b
+		return true
+	}
+}
+
+// readError classifies the error from the underlying reader.
+func readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTruncated
+	}
+	return err
+}
+
+func eof(e *errReader) bool {
+	if e.err != nil {
+		return false
+	}
+	if e.r.bounded {
+		if e.r.n < e.r.end {
+			e.fail(fmt.Errorf("%w after %s", ErrTrailingBytes, e.itemPath()))
+			return false
+		}
+		return true
+	}
+	var b [1]byte
+	if _, err := io.ReadFull(e.r, b[:]); err == nil {
+		e.fail(fmt.Errorf("%w after %s", ErrTrailingBytes, e.itemPath()))
+		return false
+	} else if err != io.EOF {
+		e.fail(err)
+		return false
 	}
 	return true
 }
 
+// makeTable allocates a table of n entries, which fails if n exceeds the limit
+// or the entries cannot fit in the rest of the input, taking up at least one byte each.
+func makeTable[T any](e *errReader, cf *ClassFile, n int) []T {
+	if e.err != nil {
+		return nil
+	}
+	if max := cf.options.Limits.maxCount(); max < n {
+		e.fail(fmt.Errorf("%w: %d entries exceed the limit of %d", ErrLimitExceeded, n, max))
+		return nil
+	}
+	if err := e.r.check(int64(n)); err != nil {
+		e.fail(readError(err))
+		return nil
+	}
+	return make([]T, n)
+}
+
 func entries[T any](es []T, f func(e *errReader) T, vs ...validator[T]) func(e *errReader) bool {
 	return func(e *errReader) bool {
 		return readEntries(e, es, f, vs...)
@@ -96,16 +404,18 @@ func readEntries[T any](e *errReader, es []T, f func(e *errReader) T, vs ...vali
 	if e.err != nil {
 		return false
 	}
+	er := new(errReader)
 	for i := range es {
-		er := &errReader{r: e.r, err: e.err, name: fmt.Sprintf("%s[%d]", e.name, i)}
+		er.reset(e, i)
 		entry := f(er)
 		if er.err != nil {
-			e.err = fmt.Errorf("fail to parse %s[%d]: %w", e.name, i, er.err)
+			e.err = er.err
 			return false
 		}
 		for _, v := range vs {
 			if err := v.validate(entry, e.name); err != nil {
-				e.err = err
+				er.fail(err)
+				e.err = er.err
 				return false
 			}
 		}
@@ -0,0 +1,22 @@
+package class
+
+import "fmt"
+
+// Span is the range of bytes which a structure occupies in the class file.
+type Span struct {
+	// Offset is the byte offset of the structure from the beginning of the class file.
+	Offset int64
+	Length int64
+}
+
+// End returns the byte offset just after the structure.
+func (s Span) End() int64 { return s.Offset + s.Length }
+
+func (s Span) String() string {
+	return fmt.Sprintf("[0x%x, 0x%x)", s.Offset, s.End())
+}
+
+// spanFrom returns the span from the offset to the current position of the reader.
+func spanFrom(er *errReader, offset int64) Span {
+	return Span{Offset: offset, Length: er.r.n - offset}
+}
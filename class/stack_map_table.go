@@ -0,0 +1,252 @@
+package class
+
+import "fmt"
+
+type verificationTypeInfo struct {
+	tag        uint8
+	cpoolIndex uint16
+	offset     uint16
+}
+
+func parseVerificationTypeInfo(er *errReader, cf *ClassFile) verificationTypeInfo {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.4
+	var v verificationTypeInfo
+	item(er, "verification_type_info.tag", integer(&v.tag, max(uint8(VerificationUninitialized))))
+
+	switch VerificationTypeTag(v.tag) {
+	case VerificationObject:
+		item(er, "Object_variable_info.cpool_index", integer(&v.cpoolIndex, constantPoolStructure[uint16, *ConstantClass](cf)))
+	case VerificationUninitialized:
+		item(er, "Uninitialized_variable_info.offset", integer(&v.offset))
+	}
+	return v
+}
+
+type stackMapFrame struct {
+	frameType   uint8
+	offsetDelta uint16
+	locals      []verificationTypeInfo
+	stack       []verificationTypeInfo
+}
+
+func parseStackMapFrame(er *errReader, cf *ClassFile) stackMapFrame {
+	var f stackMapFrame
+	item(er, "frame_type", integer(&f.frameType))
+
+	verificationTypes := func(name string, n int) []verificationTypeInfo {
+		vs := makeTable[verificationTypeInfo](er, cf, int(n))
+		item(er, name, entries(vs, func(er *errReader) verificationTypeInfo {
+			return parseVerificationTypeInfo(er, cf)
+		}))
+		return vs
+	}
+
+	switch frameKind(f.frameType) {
+	case FrameSame:
+		f.offsetDelta = uint16(f.frameType)
+	case FrameSameLocals1StackItem:
+		f.offsetDelta = uint16(f.frameType - 64)
+		f.stack = verificationTypes("stack", 1)
+	case FrameSameLocals1StackItemExtended:
+		item(er, "offset_delta", integer(&f.offsetDelta))
+		f.stack = verificationTypes("stack", 1)
+	case FrameChop, FrameSameExtended:
+		item(er, "offset_delta", integer(&f.offsetDelta))
+	case FrameAppend:
+		item(er, "offset_delta", integer(&f.offsetDelta))
+		f.locals = verificationTypes("locals", int(f.frameType-251))
+	case FrameFull:
+		item(er, "offset_delta", integer(&f.offsetDelta))
+		var n uint16
+		if item(er, "number_of_locals", integer(&n)) {
+			f.locals = verificationTypes("locals", int(n))
+		}
+		if item(er, "number_of_stack_items", integer(&n)) {
+			f.stack = verificationTypes("stack", int(n))
+		}
+	default:
+		if er.err == nil {
+			er.fail(fmt.Errorf("reserved frame_type(%d) for %s", f.frameType, er.name))
+		}
+	}
+	return f
+}
+
+type attributeStackMapTable struct {
+	attributeInfoBase
+	numberOfEntries uint16
+	entries         []stackMapFrame
+}
+
+func (base *attributeInfoBase) stackMapTable(er *errReader, cf *ClassFile) *attributeStackMapTable {
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.4
+	attr := attributeStackMapTable{attributeInfoBase: *base}
+	if item(er, "number_of_entries", integer(&attr.numberOfEntries)) {
+		attr.entries = makeTable[stackMapFrame](er, cf, int(attr.numberOfEntries))
+		item(er, "entries", entries(attr.entries, func(er *errReader) stackMapFrame {
+			return parseStackMapFrame(er, cf)
+		}))
+	}
+	return &attr
+}
+
+// FrameKind is the kind of a stack_map_frame, determined by its frame_type.
+type FrameKind uint8
+
+const (
+	FrameReserved FrameKind = iota
+	FrameSame
+	FrameSameLocals1StackItem
+	FrameSameLocals1StackItemExtended
+	FrameChop
+	FrameSameExtended
+	FrameAppend
+	FrameFull
+)
+
+func frameKind(frameType uint8) FrameKind {
+	switch {
+	case frameType <= 63:
+		return FrameSame
+	case frameType <= 127:
+		return FrameSameLocals1StackItem
+	case frameType <= 246:
+		return FrameReserved
+	case frameType == 247:
+		return FrameSameLocals1StackItemExtended
+	case frameType <= 250:
+		return FrameChop
+	case frameType == 251:
+		return FrameSameExtended
+	case frameType <= 254:
+		return FrameAppend
+	}
+	return FrameFull
+}
+
+func (k FrameKind) String() string {
+	switch k {
+	case FrameSame:
+		return "same_frame"
+	case FrameSameLocals1StackItem:
+		return "same_locals_1_stack_item_frame"
+	case FrameSameLocals1StackItemExtended:
+		return "same_locals_1_stack_item_frame_extended"
+	case FrameChop:
+		return "chop_frame"
+	case FrameSameExtended:
+		return "same_frame_extended"
+	case FrameAppend:
+		return "append_frame"
+	case FrameFull:
+		return "full_frame"
+	}
+	return "reserved"
+}
+
+// VerificationTypeTag is the tag of a verification_type_info.
+type VerificationTypeTag uint8
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.4
+const (
+	VerificationTop               VerificationTypeTag = 0
+	VerificationInteger           VerificationTypeTag = 1
+	VerificationFloat             VerificationTypeTag = 2
+	VerificationDouble            VerificationTypeTag = 3
+	VerificationLong              VerificationTypeTag = 4
+	VerificationNull              VerificationTypeTag = 5
+	VerificationUninitializedThis VerificationTypeTag = 6
+	VerificationObject            VerificationTypeTag = 7
+	VerificationUninitialized     VerificationTypeTag = 8
+)
+
+// VerificationType is a verification type of a local variable or an operand stack entry.
+type VerificationType struct {
+	Tag VerificationTypeTag
+	// ClassName is the binary name of the class, or the descriptor of the array type, for VerificationObject.
+	ClassName string
+	// Offset is the offset of the new instruction which created the object for VerificationUninitialized.
+	Offset uint16
+}
+
+func (v VerificationType) String() string {
+	switch v.Tag {
+	case VerificationTop:
+		return "top"
+	case VerificationInteger:
+		return "int"
+	case VerificationFloat:
+		return "float"
+	case VerificationDouble:
+		return "double"
+	case VerificationLong:
+		return "long"
+	case VerificationNull:
+		return "null"
+	case VerificationUninitializedThis:
+		return "uninitialized_this"
+	case VerificationObject:
+		return fmt.Sprintf("class %s", v.ClassName)
+	case VerificationUninitialized:
+		return fmt.Sprintf("uninitialized %d", v.Offset)
+	}
+	return fmt.Sprintf("unknown(%d)", v.Tag)
+}
+
+// StackMapFrame is an entry of the StackMapTable attribute.
+type StackMapFrame struct {
+	Kind        FrameKind
+	FrameType   uint8
+	OffsetDelta uint16
+	// Offset is the bytecode offset at which the frame applies.
+	Offset int
+	// ChoppedLocals is the number of the last locals which are absent for FrameChop.
+	ChoppedLocals int
+	// Locals are the additional locals for FrameAppend, or all the locals for FrameFull.
+	Locals []VerificationType
+	Stack  []VerificationType
+}
+
+// StackMapTable returns the stack map frames of the method with their absolute bytecode offsets,
+// or nil if the Code attribute has no StackMapTable attribute.
+func (c *Code) StackMapTable() []StackMapFrame {
+	attr, ok := findAttribute[*attributeStackMapTable](c.attr.attributes)
+	if !ok {
+		return nil
+	}
+
+	frames := make([]StackMapFrame, len(attr.entries))
+	offset := -1
+	for i, e := range attr.entries {
+		// offset_delta of the frames except the first one is the difference minus one
+		offset += int(e.offsetDelta) + 1
+
+		f := StackMapFrame{
+			Kind:        frameKind(e.frameType),
+			FrameType:   e.frameType,
+			OffsetDelta: e.offsetDelta,
+			Offset:      offset,
+			Locals:      c.verificationTypes(e.locals),
+			Stack:       c.verificationTypes(e.stack),
+		}
+		if f.Kind == FrameChop {
+			f.ChoppedLocals = int(251 - e.frameType)
+		}
+		frames[i] = f
+	}
+	return frames
+}
+
+func (c *Code) verificationTypes(vs []verificationTypeInfo) []VerificationType {
+	if len(vs) == 0 {
+		return nil
+	}
+	types := make([]VerificationType, len(vs))
+	for i, v := range vs {
+		types[i] = VerificationType{Tag: VerificationTypeTag(v.tag), Offset: v.offset}
+		if types[i].Tag == VerificationObject {
+			types[i].ClassName = c.cf.className(v.cpoolIndex)
+		}
+	}
+	return types
+}
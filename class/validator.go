@@ -1,19 +1,25 @@
 package class
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
+	"github.com/thara/godiva/descriptor"
+	"github.com/thara/godiva/signature"
 	"golang.org/x/exp/constraints"
 )
 
+var errOutOfRange = errors.New("out of range")
+
 func validate[T any](e *errReader, target T, vs ...validator[T]) {
 	if e.err != nil {
 		return
 	}
 	for _, v := range vs {
 		if err := v.validate(target, e.name); err != nil {
-			e.err = err
+			e.fail(err)
 			return
 		}
 	}
@@ -24,84 +30,583 @@ type validator[T any] interface {
 }
 
 func match[T any](expected T) validator[T] {
-	return &matchValidator[T]{expected: expected}
+	return &matchValidator[T]{expected: expected, err: errors.New("does not match expected value")}
 }
 
 type matchValidator[T any] struct {
 	expected T
+	err      error
 }
 
 func (v *matchValidator[T]) validate(target T, name string) error {
 	if reflect.DeepEqual(v.expected, target) {
 		return nil
 	}
-	return fmt.Errorf("%s does not match expected value (got:%v, want:%v)", name, target, v.expected)
+	return valueError(v.err, fmt.Sprintf("%v", v.expected), target)
+}
+
+func magicNumber() validator[[]byte] {
+	return &matchValidator[[]byte]{expected: []byte{0xCA, 0xFE, 0xBA, 0xBE}, err: ErrBadMagic}
+}
+
+func oneOf[T comparable](values ...T) validator[T] {
+	return &oneOfValidator[T]{values: values}
+}
+
+type oneOfValidator[T comparable] struct {
+	values []T
+}
+
+func (v *oneOfValidator[T]) validate(target T, name string) error {
+	for _, value := range v.values {
+		if value == target {
+			return nil
+		}
+	}
+	return valueError(errors.New("not allowed here"), fmt.Sprintf("one of %v", v.values), target)
 }
 
 func min[T constraints.Integer](minValue T) validator[T] {
-	return &minValidator[T]{minValue: minValue}
+	return &minValidator[T]{minValue: minValue, err: errOutOfRange}
 }
 
 type minValidator[T constraints.Integer] struct {
 	minValue T
+	err      error
 }
 
 func (v *minValidator[T]) validate(target T, name string) error {
 	if v.minValue <= target {
 		return nil
 	}
-	return fmt.Errorf("%s out of range(got:%v, min:%d)", name, target, v.minValue)
+	return valueError(v.err, fmt.Sprintf(">= %d", v.minValue), target)
+}
+
+// majorVersion validates the major_version item, which is 45 or above.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1-200-B.2
+func majorVersion() validator[uint16] {
+	return &minValidator[uint16]{minValue: 45, err: ErrUnsupportedVersion}
 }
 
 func max[T constraints.Integer](maxValue T) validator[T] {
-	return &maxValidator[T]{maxValue: maxValue}
+	return &maxValidator[T]{maxValue: maxValue, err: errOutOfRange}
 }
 
 type maxValidator[T constraints.Integer] struct {
 	maxValue T
+	err      error
 }
 
 func (v *maxValidator[T]) validate(target T, name string) error {
 	if target <= v.maxValue {
 		return nil
 	}
-	return fmt.Errorf("%s out of range(got:%v, max:%d)", name, target, v.maxValue)
+	return valueError(v.err, fmt.Sprintf("<= %d", v.maxValue), target)
+}
+
+// maxMajorVersion validates the major_version item against the newest version which the caller supports.
+func maxMajorVersion(maxVersion uint16) validator[uint16] {
+	return &maxValidator[uint16]{maxValue: maxVersion, err: ErrUnsupportedVersion}
+}
+
+// minorVersion validates the minor_version item of a class file whose major_version is 56 or above,
+// which is 0, or 65535 for a class file depending on the preview features.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1-200-B.2
+func minorVersion(major uint16) validator[uint16] {
+	return &minorVersionValidator{major: major}
+}
+
+type minorVersionValidator struct {
+	major uint16
+}
+
+func (v *minorVersionValidator) validate(target uint16, name string) error {
+	if v.major < 56 || target == 0 || target == previewMinorVersion {
+		return nil
+	}
+	return valueError(ErrUnsupportedVersion, fmt.Sprintf("0 or %d for major_version %d", previewMinorVersion, v.major), target)
+}
+
+// constantKindVersion validates that the tag of a cp_info structure is allowed in the class file version.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4-310
+func constantKindVersion(cf *ClassFile) validator[ConstantKind] {
+	return constantKindVersionValidator{cf: cf}
+}
+
+// validators which only hold the ClassFile are values, since an interface holds them without allocation.
+
+type constantKindVersionValidator struct {
+	cf *ClassFile
+}
+
+func (v constantKindVersionValidator) validate(target ConstantKind, name string) error {
+	if first, ok := constantKindVersions[target]; ok && v.cf.MajorVer < first {
+		return fmt.Errorf("%w: tag %d requires major_version %d or above, but %d", ErrUnsupportedVersion, target, first, v.cf.MajorVer)
+	}
+	return nil
 }
 
 func existConstantPool[T constraints.Integer](cf *ClassFile) validator[T] {
-	return &constantPoolExistanceValidator[T]{cp: cf.ConstantPool}
+	return constantPoolExistanceValidator[T]{cf: cf}
 }
 
 type constantPoolExistanceValidator[T constraints.Integer] struct {
-	cp []cpInfo
+	cf *ClassFile
+}
+
+func (v constantPoolExistanceValidator[T]) validate(i T, name string) error {
+	cp := v.cf.ConstantPool
+	if 1 <= i && int(i) <= len(cp) {
+		if _, ok := cp[i-1].(*ConstantUnusable); !ok {
+			return nil
+		}
+	}
+	return valueError(fmt.Errorf("%w: must be valid index in constant_pool", ErrBadConstantPoolReference),
+		fmt.Sprintf("index in 1..%d", len(cp)), i)
+}
+
+func constantPoolStructure[T constraints.Integer, V Constant](cf *ClassFile) validator[T] {
+	return constantPoolStructureValidator[T, V]{cf: cf}
+}
+
+type constantPoolStructureValidator[T constraints.Integer, V Constant] struct {
+	cf *ClassFile
+}
+
+func (v constantPoolStructureValidator[T, V]) validate(i T, name string) error {
+	cp := v.cf.ConstantPool
+	if 1 <= i && int(i) <= len(cp) {
+		if _, ok := cp[i-1].(V); ok {
+			return nil
+		}
+	}
+
+	var e V
+	typeName := reflect.TypeOf(e).Elem().Name()
+	if i < 1 || len(cp) < int(i) {
+		return valueError(fmt.Errorf("%w: must be valid index in constant_pool", ErrBadConstantPoolReference),
+			fmt.Sprintf("index of %s in 1..%d", typeName, len(cp)), i)
+	}
+	entry := cp[i-1]
+	if _, ok := entry.(*ConstantUnusable); ok {
+		return valueError(fmt.Errorf("%w: must be valid index in constant_pool, but it follows an 8-byte constant", ErrBadConstantPoolReference),
+			fmt.Sprintf("index of %s", typeName), i)
+	}
+	return valueError(fmt.Errorf("%w: constant_pool entry must be a %s structure", ErrBadConstantPoolReference, typeName),
+		typeName, fmt.Sprintf("%T at %d", entry, i))
+}
+
+func fieldDescriptor(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[fieldDescriptorFormat]{cf: cf}
+}
+
+// anyDescriptor validates a field descriptor or a method descriptor, either of which a CONSTANT_NameAndType_info
+// structure can refer to.
+func anyDescriptor(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[anyDescriptorFormat]{cf: cf}
+}
+
+func returnDescriptor(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[returnDescriptorFormat]{cf: cf}
+}
+
+// methodDescriptor validates a method descriptor, whose parameters must occupy at most 255 slots
+// including the one for `this` unless the method is static.
+func methodDescriptor(cf *ClassFile, static bool) validator[uint16] {
+	if static {
+		return utf8FormatValidator[staticMethodDescriptorFormat]{cf: cf}
+	}
+	return utf8FormatValidator[methodDescriptorFormat]{cf: cf}
+}
+
+func classSignature(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[classSignatureFormat]{cf: cf}
+}
+
+func methodSignature(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[methodSignatureFormat]{cf: cf}
+}
+
+func fieldSignature(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[fieldSignatureFormat]{cf: cf}
+}
+
+// utf8FormatValidator validates the string of a CONSTANT_Utf8_info structure against a grammar such as descriptors.
+// The grammar is given by the type parameter so that the validator holds only the ClassFile.
+type utf8FormatValidator[F utf8Format] struct {
+	cf *ClassFile
+}
+
+// utf8Format is a grammar of the strings in CONSTANT_Utf8_info structures, which is implemented by an empty struct.
+type utf8Format interface {
+	name() string
+	parse(s string) error
+}
+
+func (v utf8FormatValidator[F]) validate(i uint16, name string) error {
+	entry, err := lookupCpinfo[*ConstantUtf8](v.cf, i)
+	if err != nil {
+		return err
+	}
+	var f F
+	if err := f.parse(entry.String()); err != nil {
+		return fmt.Errorf("constant_pool entry at `%s`(%d) must be a valid %s: %w", name, i, f.name(), err)
+	}
+	return nil
+}
+
+type (
+	fieldDescriptorFormat        struct{}
+	anyDescriptorFormat          struct{}
+	returnDescriptorFormat       struct{}
+	methodDescriptorFormat       struct{}
+	staticMethodDescriptorFormat struct{}
+	classSignatureFormat         struct{}
+	methodSignatureFormat        struct{}
+	fieldSignatureFormat         struct{}
+	unqualifiedNameFormat        struct{}
+	methodNameFormat             struct{}
+	methodRefNameFormat          struct{}
+	classNameFormat              struct{}
+	moduleNameFormat             struct{}
+	packageNameFormat            struct{}
+)
+
+func (fieldDescriptorFormat) name() string { return "field descriptor" }
+func (fieldDescriptorFormat) parse(s string) error {
+	_, err := descriptor.ParseField(s)
+	return err
+}
+
+func (anyDescriptorFormat) name() string { return "field or method descriptor" }
+func (anyDescriptorFormat) parse(s string) error {
+	if strings.HasPrefix(s, "(") {
+		return parseMethodDescriptor(s, true)
+	}
+	_, err := descriptor.ParseField(s)
+	return err
+}
+
+func (returnDescriptorFormat) name() string { return "return descriptor" }
+func (returnDescriptorFormat) parse(s string) error {
+	_, err := descriptor.ParseReturn(s)
+	return err
+}
+
+func (methodDescriptorFormat) name() string         { return "method descriptor" }
+func (methodDescriptorFormat) parse(s string) error { return parseMethodDescriptor(s, false) }
+
+func (staticMethodDescriptorFormat) name() string         { return "method descriptor" }
+func (staticMethodDescriptorFormat) parse(s string) error { return parseMethodDescriptor(s, true) }
+
+func parseMethodDescriptor(s string, static bool) error {
+	m, err := descriptor.ParseMethod(s)
+	if err != nil {
+		return err
+	}
+	slots := m.ParamSlots()
+	if !static {
+		slots++
+	}
+	if 255 < slots {
+		return fmt.Errorf("parameters occupy %d slots, more than 255", slots)
+	}
+	return nil
+}
+
+func (classSignatureFormat) name() string { return "class signature" }
+func (classSignatureFormat) parse(s string) error {
+	_, err := signature.ParseClass(s)
+	return err
 }
 
-func (v *constantPoolExistanceValidator[T]) validate(i T, name string) error {
-	if 1 <= i && int(i) <= len(v.cp) {
+func (methodSignatureFormat) name() string { return "method signature" }
+func (methodSignatureFormat) parse(s string) error {
+	_, err := signature.ParseMethod(s)
+	return err
+}
+
+func (fieldSignatureFormat) name() string { return "field signature" }
+func (fieldSignatureFormat) parse(s string) error {
+	_, err := signature.ParseField(s)
+	return err
+}
+
+// constantValueType validates the constant value of a field against the field descriptor.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.2
+func constantValueType(cf *ClassFile, descriptorIndex uint16) validator[uint16] {
+	return &constantValueTypeValidator{cf: cf, descriptorIndex: descriptorIndex}
+}
+
+type constantValueTypeValidator struct {
+	cf              *ClassFile
+	descriptorIndex uint16
+}
+
+func (v *constantValueTypeValidator) validate(i uint16, name string) error {
+	desc := v.cf.utf8(v.descriptorIndex)
+
+	var tag ConstantKind
+	var typeName string
+	switch desc {
+	case "I", "S", "C", "B", "Z":
+		tag, typeName = ConstantKindInteger, "CONSTANT_Integer"
+	case "F":
+		tag, typeName = ConstantKindFloat, "CONSTANT_Float"
+	case "J":
+		tag, typeName = ConstantKindLong, "CONSTANT_Long"
+	case "D":
+		tag, typeName = ConstantKindDouble, "CONSTANT_Double"
+	case "Ljava/lang/String;":
+		tag, typeName = ConstantKindString, "CONSTANT_String"
+	default:
+		return fmt.Errorf("%w: constant_pool entry at `%s`(%d) is not allowed for a field of type %s", ErrBadConstantPoolReference, name, i, desc)
+	}
+
+	entry, ok := v.cf.lookupConstantPool(i)
+	if !ok || entry.Tag() != tag {
+		return fmt.Errorf("%w: constant_pool entry at `%s`(%d) must be a %s structure for a field of type %s", ErrBadConstantPoolReference, name, i, typeName, desc)
+	}
+	return nil
+}
+
+// memberNameAndType validates the CONSTANT_NameAndType_info structure referred by a constant_pool entry of the kind.
+// The descriptor is a field descriptor for a field or a dynamically-computed constant, and a method descriptor otherwise.
+// A method referred by CONSTANT_Methodref_info or CONSTANT_InterfaceMethodref_info may be named <init>
+// but not <clinit>, and <init> must return void.
+// The grammar of the name and the descriptor is left to the check of the CONSTANT_NameAndType_info structure itself.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4.2
+func memberNameAndType(cf *ClassFile, kind ConstantKind) validator[uint16] {
+	return &memberNameAndTypeValidator{cf: cf, kind: kind}
+}
+
+type memberNameAndTypeValidator struct {
+	cf   *ClassFile
+	kind ConstantKind
+}
+
+func (v *memberNameAndTypeValidator) validate(i uint16, name string) error {
+	if err := constantPoolStructure[uint16, *ConstantNameAndType](v.cf).validate(i, name); err != nil {
+		return err
+	}
+	nt := v.cf.ConstantPool[i-1].(*ConstantNameAndType)
+	member, desc := nt.Name(), nt.Descriptor()
+
+	switch v.kind {
+	case ConstantKindFieldref, ConstantKindDynamic:
+		if strings.HasPrefix(desc, "(") {
+			return valueError(fmt.Errorf("%w: %s must refer to a field descriptor", ErrBadConstantPoolReference, name), "field descriptor", desc)
+		}
+		return nil
+	case ConstantKindMethodref, ConstantKindInterfaceMethodref:
+		if err := (methodRefNameFormat{}).parse(member); err != nil {
+			return valueError(fmt.Errorf("%w: %s must refer to a valid method name: %v", ErrBadConstantPoolReference, name, err), "method name", member)
+		}
+	default:
+		if err := (methodNameFormat{}).parse(member); err != nil {
+			return valueError(fmt.Errorf("%w: %s must refer to a valid method name: %v", ErrBadConstantPoolReference, name, err), "method name", member)
+		}
+	}
+	if !strings.HasPrefix(desc, "(") {
+		return valueError(fmt.Errorf("%w: %s must refer to a method descriptor", ErrBadConstantPoolReference, name), "method descriptor", desc)
+	}
+
+	// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-2.html#jvms-2.9.1
+	// only a method named <init> is an instance initialization method, while a field can be named <init>
+	if v.kind != ConstantKindInvokeDynamic && member == "<init>" && !strings.HasSuffix(desc, ")V") {
+		return valueError(fmt.Errorf("%w: <init> must be a method returning void", ErrBadConstantPoolReference),
+			"method descriptor returning void", desc)
+	}
+	return nil
+}
+
+// methodHandleReference validates the reference_index of a CONSTANT_MethodHandle_info structure
+// against its reference_kind. An invalid reference_kind is left to be reported by itself.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4.8
+func methodHandleReference(cf *ClassFile, kind ReferenceKind) validator[uint16] {
+	return &methodHandleReferenceValidator{cf: cf, kind: kind}
+}
+
+type methodHandleReferenceValidator struct {
+	cf   *ClassFile
+	kind ReferenceKind
+}
+
+func (v *methodHandleReferenceValidator) validate(i uint16, name string) error {
+	switch v.kind {
+	case RefGetField, RefGetStatic, RefPutField, RefPutStatic:
+		return constantPoolStructure[uint16, *ConstantFieldref](v.cf).validate(i, name)
+	case RefInvokeVirtual, RefNewInvokeSpecial:
+		if err := constantPoolStructure[uint16, *ConstantMethodref](v.cf).validate(i, name); err != nil {
+			return err
+		}
+	case RefInvokeStatic, RefInvokeSpecial:
+		if v.cf.MajorVer < 52 {
+			if err := constantPoolStructure[uint16, *ConstantMethodref](v.cf).validate(i, name); err != nil {
+				return err
+			}
+			break
+		}
+		if err := constantPoolStructure[uint16, *ConstantInterfaceMethodref](v.cf).validate(i, name); err != nil {
+			if err := constantPoolStructure[uint16, *ConstantMethodref](v.cf).validate(i, name); err != nil {
+				entry, _ := v.cf.lookupConstantPool(i)
+				return valueError(fmt.Errorf("%w: constant_pool entry must be a ConstantMethodref or ConstantInterfaceMethodref structure", ErrBadConstantPoolReference),
+					"ConstantMethodref or ConstantInterfaceMethodref", fmt.Sprintf("%T at %d", entry, i))
+			}
+		}
+	case RefInvokeInterface:
+		if err := constantPoolStructure[uint16, *ConstantInterfaceMethodref](v.cf).validate(i, name); err != nil {
+			return err
+		}
+	default:
 		return nil
 	}
-	return fmt.Errorf("%s(%d) must be valid index in constant_pool", name, i)
+
+	method := v.cf.ConstantPool[i-1].(interface{ Name() string }).Name()
+	if v.kind == RefNewInvokeSpecial {
+		if method != "<init>" {
+			return valueError(fmt.Errorf("%w: method handle of %s must refer to <init>", ErrBadConstantPoolReference, v.kind), "<init>", method)
+		}
+	} else if method == "<init>" || method == "<clinit>" {
+		return valueError(fmt.Errorf("%w: method handle of %s must not refer to %s", ErrBadConstantPoolReference, v.kind, method),
+			"method other than <init> and <clinit>", method)
+	}
+	return nil
 }
 
-func constantPoolStructure[T constraints.Integer, V cpInfo](cf *ClassFile) validator[T] {
-	return &constantPoolStructureValidator[T, V]{cp: cf.ConstantPool}
+// bootstrapMethodAttr validates the bootstrap_method_attr_index of a CONSTANT_Dynamic_info or
+// CONSTANT_InvokeDynamic_info structure, which is an index into the bootstrap_methods array of the BootstrapMethods attribute.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4.10
+func bootstrapMethodAttr(cf *ClassFile) validator[uint16] {
+	return &bootstrapMethodAttrValidator{cf: cf}
 }
 
-type constantPoolStructureValidator[T constraints.Integer, V cpInfo] struct {
-	cp []cpInfo
+type bootstrapMethodAttrValidator struct {
+	cf *ClassFile
 }
 
-func (v *constantPoolStructureValidator[T, V]) validate(i T, name string) error {
-	if i < 1 || len(v.cp) < int(i) {
-		return fmt.Errorf("%s(%d) must be valid index in constant_pool", name, i)
+func (v *bootstrapMethodAttrValidator) validate(i uint16, name string) error {
+	attr, ok := findAttribute[*attributeBootstrapMethods](v.cf.attributes)
+	if !ok {
+		return fmt.Errorf("%w: BootstrapMethods attribute must exist for `%s`(%d)", ErrBadConstantPoolReference, name, i)
 	}
+	if int(attr.numBootstrapMethods) <= int(i) {
+		return valueError(fmt.Errorf("%w: must be valid index in bootstrap_methods", ErrBadConstantPoolReference),
+			fmt.Sprintf("index in 0..%d", int(attr.numBootstrapMethods)-1), i)
+	}
+	return nil
+}
+
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.2
+
+func unqualifiedName(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[unqualifiedNameFormat]{cf: cf}
+}
+
+func methodName(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[methodNameFormat]{cf: cf}
+}
+
+// classEntryName validates the name of a CONSTANT_Class_info structure, which is a binary class name
+// or the descriptor of an array type.
+func classEntryName(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[classNameFormat]{cf: cf}
+}
+
+func moduleEntryName(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[moduleNameFormat]{cf: cf}
+}
+
+func packageEntryName(cf *ClassFile) validator[uint16] {
+	return utf8FormatValidator[packageNameFormat]{cf: cf}
+}
+
+func (unqualifiedNameFormat) name() string         { return "unqualified name" }
+func (unqualifiedNameFormat) parse(s string) error { return validateUnqualifiedName(s) }
+
+func (methodNameFormat) name() string         { return "method name" }
+func (methodNameFormat) parse(s string) error { return validateMethodName(s) }
 
-	entry := v.cp[i-1]
-	_, ok := entry.(V)
-	if ok {
+// methodRefNameFormat is the name of a method referred by CONSTANT_Methodref_info or CONSTANT_InterfaceMethodref_info,
+// which cannot be <clinit>.
+func (methodRefNameFormat) name() string { return "method name" }
+func (methodRefNameFormat) parse(s string) error {
+	if s == "<clinit>" {
+		return errors.New("<clinit> cannot be referred")
+	}
+	return validateMethodName(s)
+}
+
+func (classNameFormat) name() string { return "class name" }
+func (classNameFormat) parse(s string) error {
+	if strings.HasPrefix(s, "[") {
+		_, err := descriptor.ParseField(s)
+		return err
+	}
+	return descriptor.ValidateBinaryName(s)
+}
+
+func (moduleNameFormat) name() string         { return "module name" }
+func (moduleNameFormat) parse(s string) error { return validateModuleName(s) }
+
+func (packageNameFormat) name() string         { return "package name" }
+func (packageNameFormat) parse(s string) error { return descriptor.ValidateBinaryName(s) }
+
+// validateUnqualifiedName validates a name of a field, local variable or formal parameter.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.2.2
+func validateUnqualifiedName(s string) error {
+	if s == "" {
+		return errors.New("empty name")
+	}
+	if i := strings.IndexAny(s, ".;[/"); 0 <= i {
+		return fmt.Errorf("%q contains %q", s, s[i])
+	}
+	return nil
+}
+
+// validateMethodName validates a method name, which is an unqualified name without '<' and '>',
+// or one of the special names <init> and <clinit>.
+func validateMethodName(s string) error {
+	if s == "<init>" || s == "<clinit>" {
 		return nil
 	}
-	var e V
-	typeName := reflect.TypeOf(e).Name()
-	return fmt.Errorf("constant_pool entry at `%s`(%d) must be a %s structure", name, i, typeName)
+	if err := validateUnqualifiedName(s); err != nil {
+		return err
+	}
+	if i := strings.IndexAny(s, "<>"); 0 <= i {
+		return fmt.Errorf("%q contains %q", s, s[i])
+	}
+	return nil
+}
+
+// validateModuleName validates a module name, in which '\' escapes only '\', ':' and '@',
+// and ':', '@' and the characters from U+0000 to U+001F are not allowed otherwise.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.2.3
+func validateModuleName(s string) error {
+	if s == "" {
+		return errors.New("empty name")
+	}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\':
+			if len(s) <= i+1 || !strings.ContainsRune(`\:@`, rune(s[i+1])) {
+				return fmt.Errorf("%q contains an invalid escape at %d", s, i)
+			}
+			i++
+		case c == ':' || c == '@':
+			return fmt.Errorf("%q contains unescaped %q", s, c)
+		case c < 0x20:
+			return fmt.Errorf("%q contains a control character", s)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,75 @@
+package class
+
+import "fmt"
+
+// previewMinorVersion is the minor_version of a class file which depends on the preview features.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1-200-B.2
+const previewMinorVersion uint16 = 0xFFFF
+
+// JavaRelease returns the Java SE release of the class file version, such as "Java 18".
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.1-200-B.2
+func (c *ClassFile) JavaRelease() string {
+	switch {
+	case c.MajorVer == 45 && c.MinorVer <= 3:
+		return "Java 1.0.2"
+	case c.MajorVer < 49:
+		return fmt.Sprintf("Java 1.%d", c.MajorVer-44)
+	}
+	return fmt.Sprintf("Java %d", c.MajorVer-44)
+}
+
+// IsPreview reports whether the class file depends on the preview features of its Java SE release.
+func (c *ClassFile) IsPreview() bool {
+	return 56 <= c.MajorVer && c.MinorVer == previewMinorVersion
+}
+
+// constantKindVersions is the first major version in which each constant kind is allowed.
+// The constant kinds which are not listed are allowed in every version.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.4-310
+var constantKindVersions = map[ConstantKind]uint16{
+	ConstantKindMethodHandle:  51,
+	ConstantKindMethodType:    51,
+	ConstantKindDynamic:       55,
+	ConstantKindInvokeDynamic: 51,
+	ConstantKindModule:        53,
+	ConstantKindPackage:       53,
+}
+
+// attributeVersions is the first major version in which each attribute is predefined.
+// The attributes which are not listed are predefined in every version.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7-320
+var attributeVersions = map[string]uint16{
+	"EnclosingMethod":                      49,
+	"Signature":                            49,
+	"SourceDebugExtension":                 49,
+	"LocalVariableTypeTable":               49,
+	"RuntimeVisibleAnnotations":            49,
+	"RuntimeInvisibleAnnotations":          49,
+	"RuntimeVisibleParameterAnnotations":   49,
+	"RuntimeInvisibleParameterAnnotations": 49,
+	"AnnotationDefault":                    49,
+	"StackMapTable":                        50,
+	"BootstrapMethods":                     51,
+	"RuntimeVisibleTypeAnnotations":        52,
+	"RuntimeInvisibleTypeAnnotations":      52,
+	"MethodParameters":                     52,
+	"Module":                               53,
+	"ModulePackages":                       53,
+	"ModuleMainClass":                      53,
+	"NestHost":                             55,
+	"NestMembers":                          55,
+	"Record":                               60,
+	"PermittedSubclasses":                  61,
+}
+
+// predefinedAttribute reports whether the attribute is predefined in the class file version.
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7
+// An attribute which is not predefined must be treated as an unrecognized attribute.
+func (c *ClassFile) predefinedAttribute(name string) bool {
+	v, ok := attributeVersions[name]
+	return !ok || v <= c.MajorVer
+}
@@ -0,0 +1,306 @@
+// Package descriptor parses field descriptors and method descriptors.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.3
+package descriptor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMalformed is the error wrapped by the errors for malformed descriptors.
+var ErrMalformed = errors.New("malformed descriptor")
+
+// MaxArrayDimensions is the maximum number of the dimensions of an array type.
+const MaxArrayDimensions = 255
+
+// Type is a type in a field descriptor or a method descriptor.
+type Type interface {
+	// Descriptor returns the type in the descriptor form, such as "[Ljava/lang/String;".
+	Descriptor() string
+	// JavaName returns the type in the Java source form, such as "java.lang.String[]".
+	JavaName() string
+	// Slots returns the number of the local variable slots which a value of the type occupies.
+	Slots() int
+
+	String() string
+}
+
+// BaseType is a primitive type.
+type BaseType byte
+
+const (
+	Byte    BaseType = 'B'
+	Char    BaseType = 'C'
+	Double  BaseType = 'D'
+	Float   BaseType = 'F'
+	Int     BaseType = 'I'
+	Long    BaseType = 'J'
+	Short   BaseType = 'S'
+	Boolean BaseType = 'Z'
+)
+
+func (t BaseType) Descriptor() string { return string(t) }
+
+func (t BaseType) JavaName() string {
+	switch t {
+	case Byte:
+		return "byte"
+	case Char:
+		return "char"
+	case Double:
+		return "double"
+	case Float:
+		return "float"
+	case Int:
+		return "int"
+	case Long:
+		return "long"
+	case Short:
+		return "short"
+	case Boolean:
+		return "boolean"
+	}
+	return fmt.Sprintf("BaseType(%q)", byte(t))
+}
+
+func (t BaseType) Slots() int {
+	if t == Long || t == Double {
+		return 2
+	}
+	return 1
+}
+
+func (t BaseType) String() string { return t.JavaName() }
+
+// ObjectType is a class or interface type.
+type ObjectType struct {
+	// ClassName is the binary name of the class or interface in internal form, such as "java/lang/String".
+	ClassName string
+}
+
+func (t ObjectType) Descriptor() string { return "L" + t.ClassName + ";" }
+
+func (t ObjectType) JavaName() string { return strings.ReplaceAll(t.ClassName, "/", ".") }
+
+func (t ObjectType) Slots() int { return 1 }
+
+func (t ObjectType) String() string { return t.JavaName() }
+
+// ArrayType is an array type.
+type ArrayType struct {
+	Dimensions int
+	// Element is the type of the elements of the innermost array, which is a BaseType or an ObjectType.
+	Element Type
+}
+
+func (t ArrayType) Descriptor() string {
+	return strings.Repeat("[", t.Dimensions) + t.Element.Descriptor()
+}
+
+func (t ArrayType) JavaName() string {
+	return t.Element.JavaName() + strings.Repeat("[]", t.Dimensions)
+}
+
+func (t ArrayType) Slots() int { return 1 }
+
+func (t ArrayType) String() string { return t.JavaName() }
+
+// VoidType is the return type of a method which returns no value.
+type VoidType struct{}
+
+func (VoidType) Descriptor() string { return "V" }
+
+func (VoidType) JavaName() string { return "void" }
+
+func (VoidType) Slots() int { return 0 }
+
+func (t VoidType) String() string { return t.JavaName() }
+
+// Method is a method descriptor.
+type Method struct {
+	Params []Type
+	// Return is the return type, which is VoidType if the method returns no value.
+	Return Type
+}
+
+// Descriptor returns the method descriptor, such as "([Ljava/lang/String;)V".
+func (m *Method) Descriptor() string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, p := range m.Params {
+		b.WriteString(p.Descriptor())
+	}
+	b.WriteByte(')')
+	b.WriteString(m.Return.Descriptor())
+	return b.String()
+}
+
+// ParamSlots returns the number of the local variable slots which the parameters occupy,
+// not including the one for `this` of instance methods.
+func (m *Method) ParamSlots() int {
+	n := 0
+	for _, p := range m.Params {
+		n += p.Slots()
+	}
+	return n
+}
+
+// JavaDeclaration returns the method in the Java source form with the name, such as "void main(java.lang.String[])".
+func (m *Method) JavaDeclaration(name string) string {
+	params := make([]string, len(m.Params))
+	for i, p := range m.Params {
+		params[i] = p.JavaName()
+	}
+	return fmt.Sprintf("%s %s(%s)", m.Return.JavaName(), name, strings.Join(params, ", "))
+}
+
+// ParseField parses a field descriptor.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.3.2
+func ParseField(s string) (Type, error) {
+	p := parser{s: s}
+	t := p.fieldType()
+	if p.err == nil && p.pos != len(s) {
+		p.fail("unexpected trailing characters")
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return t, nil
+}
+
+// ParseMethod parses a method descriptor.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.3.3
+func ParseMethod(s string) (*Method, error) {
+	p := parser{s: s}
+	m := p.method()
+	if p.err == nil && p.pos != len(s) {
+		p.fail("unexpected trailing characters")
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return m, nil
+}
+
+// ParseReturn parses a return descriptor, which is a field descriptor or "V".
+func ParseReturn(s string) (Type, error) {
+	if s == "V" {
+		return VoidType{}, nil
+	}
+	return ParseField(s)
+}
+
+type parser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *parser) fail(msg string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%w %q at %d: %s", ErrMalformed, p.s, p.pos, msg)
+	}
+}
+
+func (p *parser) fieldType() Type {
+	if p.err != nil {
+		return nil
+	}
+	if len(p.s) <= p.pos {
+		p.fail("missing field type")
+		return nil
+	}
+
+	switch c := p.s[p.pos]; c {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z':
+		p.pos++
+		return BaseType(c)
+	case 'L':
+		p.pos++
+		end := strings.IndexByte(p.s[p.pos:], ';')
+		if end < 0 {
+			p.fail("missing ';' of the object type")
+			return nil
+		}
+		name := p.s[p.pos : p.pos+end]
+		if err := ValidateBinaryName(name); err != nil {
+			p.fail(fmt.Sprintf("invalid class name: %v", err))
+			return nil
+		}
+		p.pos += end + 1
+		return ObjectType{ClassName: name}
+	case '[':
+		start := p.pos
+		for p.pos < len(p.s) && p.s[p.pos] == '[' {
+			p.pos++
+		}
+		dims := p.pos - start
+		if MaxArrayDimensions < dims {
+			p.pos = start
+			p.fail(fmt.Sprintf("more than %d array dimensions", MaxArrayDimensions))
+			return nil
+		}
+		elem := p.fieldType()
+		if p.err != nil {
+			return nil
+		}
+		return ArrayType{Dimensions: dims, Element: elem}
+	}
+	p.fail(fmt.Sprintf("unexpected character %q", p.s[p.pos]))
+	return nil
+}
+
+func (p *parser) method() *Method {
+	if len(p.s) == 0 || p.s[0] != '(' {
+		p.fail("missing '('")
+		return nil
+	}
+	p.pos++
+
+	m := Method{}
+	for p.err == nil && p.pos < len(p.s) && p.s[p.pos] != ')' {
+		m.Params = append(m.Params, p.fieldType())
+	}
+	if p.err != nil {
+		return nil
+	}
+	if len(p.s) <= p.pos {
+		p.fail("missing ')'")
+		return nil
+	}
+	p.pos++
+
+	if p.pos < len(p.s) && p.s[p.pos] == 'V' {
+		p.pos++
+		m.Return = VoidType{}
+	} else {
+		m.Return = p.fieldType()
+	}
+	if p.err != nil {
+		return nil
+	}
+	return &m
+}
+
+// ValidateBinaryName validates a binary class or package name in internal form, such as "java/lang/Object",
+// whose identifiers separated by '/' are unqualified names.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.2.1
+func ValidateBinaryName(name string) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	for _, id := range strings.Split(name, "/") {
+		if id == "" {
+			return fmt.Errorf("%q contains an empty identifier", name)
+		}
+		if i := strings.IndexAny(id, ".;["); 0 <= i {
+			return fmt.Errorf("%q contains an invalid identifier: %q contains %q", name, id, id[i])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package descriptor_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/thara/godiva/descriptor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		descriptor string
+		want       Type
+		javaName   string
+		slots      int
+	}{
+		{"I", Int, "int", 1},
+		{"J", Long, "long", 2},
+		{"D", Double, "double", 2},
+		{"Z", Boolean, "boolean", 1},
+		{"Ljava/lang/String;", ObjectType{ClassName: "java/lang/String"}, "java.lang.String", 1},
+		{"[Ljava/lang/String;", ArrayType{Dimensions: 1, Element: ObjectType{ClassName: "java/lang/String"}}, "java.lang.String[]", 1},
+		{"[[J", ArrayType{Dimensions: 2, Element: Long}, "long[][]", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.descriptor, func(t *testing.T) {
+			got, err := ParseField(tt.descriptor)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.descriptor, got.Descriptor())
+			assert.Equal(t, tt.javaName, got.JavaName())
+			assert.Equal(t, tt.slots, got.Slots())
+		})
+	}
+
+	for _, s := range []string{
+		"", "V", "X", "II", "L;", "Ljava/lang/String", "Ljava.lang.String;", "Ljava//String;", "[", "[V",
+		strings.Repeat("[", 256) + "I",
+	} {
+		_, err := ParseField(s)
+		assert.ErrorIs(t, err, ErrMalformed, s)
+	}
+	_, err := ParseField(strings.Repeat("[", 255) + "I")
+	assert.NoError(t, err)
+}
+
+func TestParseMethod(t *testing.T) {
+	m, err := ParseMethod("(IDLjava/lang/Thread;)Ljava/lang/Object;")
+	require.NoError(t, err)
+	assert.Equal(t, []Type{Int, Double, ObjectType{ClassName: "java/lang/Thread"}}, m.Params)
+	assert.Equal(t, ObjectType{ClassName: "java/lang/Object"}, m.Return)
+	assert.Equal(t, 4, m.ParamSlots())
+	assert.Equal(t, "(IDLjava/lang/Thread;)Ljava/lang/Object;", m.Descriptor())
+	assert.Equal(t, "java.lang.Object m(int, double, java.lang.Thread)", m.JavaDeclaration("m"))
+
+	m, err = ParseMethod("([Ljava/lang/String;)V")
+	require.NoError(t, err)
+	assert.Equal(t, VoidType{}, m.Return)
+	assert.Equal(t, "void main(java.lang.String[])", m.JavaDeclaration("main"))
+
+	m, err = ParseMethod("()V")
+	require.NoError(t, err)
+	assert.Empty(t, m.Params)
+	assert.Equal(t, 0, m.ParamSlots())
+
+	for _, s := range []string{"", "V", "()", "(V)V", "(I", "(I)VV", "I)V", "()X"} {
+		_, err := ParseMethod(s)
+		assert.ErrorIs(t, err, ErrMalformed, s)
+	}
+}
+
+func TestParseReturn(t *testing.T) {
+	got, err := ParseReturn("V")
+	require.NoError(t, err)
+	assert.Equal(t, VoidType{}, got)
+
+	got, err = ParseReturn("Ljava/lang/String;")
+	require.NoError(t, err)
+	assert.Equal(t, "java.lang.String", got.JavaName())
+}
+
+func TestValidateBinaryName(t *testing.T) {
+	for _, name := range []string{"Object", "java/lang/Object", "java/util/Map$Entry", "module-info"} {
+		assert.NoError(t, ValidateBinaryName(name), name)
+	}
+	for _, name := range []string{"", "/", "java//Object", "java/lang/", "java.lang.Object", "java/lang/Object;", "[I"} {
+		assert.Error(t, ValidateBinaryName(name), name)
+	}
+}
@@ -2,10 +2,13 @@ module github.com/thara/godiva
 
 go 1.18
 
+require (
+	github.com/stretchr/testify v1.8.0
+	golang.org/x/exp v0.0.0-20220827204233-334a2380cb91
+)
+
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.8.0 // indirect
-	golang.org/x/exp v0.0.0-20220827204233-334a2380cb91 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
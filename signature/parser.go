@@ -0,0 +1,237 @@
+package signature
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thara/godiva/descriptor"
+)
+
+// ParseClass parses a class signature.
+func ParseClass(s string) (*ClassSignature, error) {
+	p := parser{s: s}
+	var sig ClassSignature
+	sig.TypeParameters = p.typeParameters()
+	sig.Superclass = p.classType()
+	for p.err == nil && p.pos < len(s) {
+		sig.Interfaces = append(sig.Interfaces, p.classType())
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &sig, nil
+}
+
+// ParseMethod parses a method signature.
+func ParseMethod(s string) (*MethodSignature, error) {
+	p := parser{s: s}
+	var sig MethodSignature
+	sig.TypeParameters = p.typeParameters()
+	p.expect('(')
+	for p.err == nil && p.peek() != ')' {
+		sig.Parameters = append(sig.Parameters, p.javaType())
+	}
+	p.expect(')')
+	if p.peek() == 'V' {
+		p.pos++
+		sig.Result = VoidType{}
+	} else {
+		sig.Result = p.javaType()
+	}
+	for p.err == nil && p.pos < len(s) {
+		p.expect('^')
+		if p.peek() == 'T' {
+			sig.Throws = append(sig.Throws, p.typeVariable())
+		} else {
+			sig.Throws = append(sig.Throws, p.classType())
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &sig, nil
+}
+
+// ParseField parses a field signature, which is a reference type.
+func ParseField(s string) (Type, error) {
+	p := parser{s: s}
+	t := p.referenceType()
+	if p.err == nil && p.pos != len(s) {
+		p.fail("unexpected trailing characters")
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return t, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+	err error
+}
+
+func (p *parser) fail(msg string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%w %q at %d: %s", ErrMalformed, p.s, p.pos, msg)
+	}
+}
+
+// peek returns the next character, or 0 at the end or after an error.
+func (p *parser) peek() byte {
+	if p.err != nil || len(p.s) <= p.pos {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) expect(c byte) {
+	if p.err != nil {
+		return
+	}
+	if p.peek() != c {
+		p.fail(fmt.Sprintf("missing %q", c))
+		return
+	}
+	p.pos++
+}
+
+// identifier reads an Identifier, which ends at any of the characters . ; [ / < > :
+func (p *parser) identifier() string {
+	if p.err != nil {
+		return ""
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(".;[/<>:", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		p.fail("missing identifier")
+		return ""
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *parser) typeParameters() []TypeParameter {
+	if p.peek() != '<' {
+		return nil
+	}
+	p.pos++
+
+	var ps []TypeParameter
+	for p.err == nil {
+		var tp TypeParameter
+		tp.Name = p.identifier()
+		p.expect(':')
+		if c := p.peek(); c != ':' && c != '>' {
+			tp.ClassBound = p.referenceType()
+		}
+		for p.err == nil && p.peek() == ':' {
+			p.pos++
+			tp.InterfaceBounds = append(tp.InterfaceBounds, p.referenceType())
+		}
+		ps = append(ps, tp)
+		if p.peek() == '>' {
+			break
+		}
+	}
+	p.expect('>')
+	return ps
+}
+
+func (p *parser) javaType() Type {
+	switch c := p.peek(); c {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z':
+		p.pos++
+		return BaseType{descriptor.BaseType(c)}
+	}
+	return p.referenceType()
+}
+
+func (p *parser) referenceType() Type {
+	switch p.peek() {
+	case 'L':
+		return p.classType()
+	case 'T':
+		return p.typeVariable()
+	case '[':
+		p.pos++
+		elem := p.javaType()
+		if p.err != nil {
+			return nil
+		}
+		return &ArrayType{Element: elem}
+	case 0:
+		p.fail("missing reference type")
+	default:
+		p.fail(fmt.Sprintf("unexpected character %q", p.s[p.pos]))
+	}
+	return nil
+}
+
+func (p *parser) typeVariable() *TypeVariable {
+	p.expect('T')
+	name := p.identifier()
+	p.expect(';')
+	if p.err != nil {
+		return nil
+	}
+	return &TypeVariable{Name: name}
+}
+
+func (p *parser) classType() *ClassType {
+	p.expect('L')
+
+	var t ClassType
+	var pkg []string
+	name := p.identifier()
+	for p.peek() == '/' {
+		p.pos++
+		pkg = append(pkg, name)
+		name = p.identifier()
+	}
+	t.Package = strings.Join(pkg, "/")
+
+	for p.err == nil {
+		c := SimpleClassType{Name: name}
+		if p.peek() == '<' {
+			c.TypeArguments = p.typeArguments()
+		}
+		t.Classes = append(t.Classes, c)
+		if p.peek() != '.' {
+			break
+		}
+		p.pos++
+		name = p.identifier()
+	}
+	p.expect(';')
+	if p.err != nil {
+		return nil
+	}
+	return &t
+}
+
+func (p *parser) typeArguments() []TypeArgument {
+	p.expect('<')
+	var args []TypeArgument
+	for p.err == nil {
+		var a TypeArgument
+		switch c := p.peek(); c {
+		case '*':
+			p.pos++
+			a.Wildcard = WildcardUnbounded
+		case '+', '-':
+			p.pos++
+			a.Wildcard = Wildcard(c)
+			a.Type = p.referenceType()
+		default:
+			a.Type = p.referenceType()
+		}
+		args = append(args, a)
+		if p.peek() == '>' {
+			break
+		}
+	}
+	p.expect('>')
+	return args
+}
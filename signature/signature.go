@@ -0,0 +1,346 @@
+// Package signature parses the generic signatures of classes, methods and fields.
+//
+// https://docs.oracle.com/javase/specs/jvms/se18/html/jvms-4.html#jvms-4.7.9.1
+package signature
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/thara/godiva/descriptor"
+)
+
+// ErrMalformed is the error wrapped by the errors for malformed signatures.
+var ErrMalformed = errors.New("malformed signature")
+
+// Type is a Java type in a signature, which is a BaseType, *ClassType, *TypeVariable, *ArrayType or VoidType.
+type Type interface {
+	// Signature returns the type in the signature form, such as "Ljava/util/List<TT;>;".
+	Signature() string
+	// JavaName returns the type in the Java source form, such as "java.util.List<T>".
+	JavaName() string
+
+	String() string
+}
+
+// BaseType is a primitive type.
+type BaseType struct {
+	descriptor.BaseType
+}
+
+func (t BaseType) Signature() string { return t.Descriptor() }
+
+// VoidType is the result of a method which returns no value.
+type VoidType struct {
+	descriptor.VoidType
+}
+
+func (t VoidType) Signature() string { return t.Descriptor() }
+
+// ClassType is a parameterized or non-parameterized class or interface type.
+type ClassType struct {
+	// Package is the package name in internal form, such as "java/util", or empty for the unnamed package.
+	Package string
+	// Classes are the outermost class followed by its nested classes, such as Map and Entry of Map<K, V>.Entry.
+	Classes []SimpleClassType
+}
+
+// SimpleClassType is a class in a ClassType with its type arguments.
+type SimpleClassType struct {
+	Name          string
+	TypeArguments []TypeArgument
+}
+
+// ClassName returns the binary name of the class in internal form, such as "java/util/Map$Entry".
+func (t *ClassType) ClassName() string {
+	names := make([]string, len(t.Classes))
+	for i, c := range t.Classes {
+		names[i] = c.Name
+	}
+	name := strings.Join(names, "$")
+	if t.Package == "" {
+		return name
+	}
+	return t.Package + "/" + name
+}
+
+func (t *ClassType) Signature() string {
+	var b strings.Builder
+	b.WriteByte('L')
+	if t.Package != "" {
+		b.WriteString(t.Package)
+		b.WriteByte('/')
+	}
+	for i, c := range t.Classes {
+		if 0 < i {
+			b.WriteByte('.')
+		}
+		b.WriteString(c.Name)
+		if 0 < len(c.TypeArguments) {
+			b.WriteByte('<')
+			for _, a := range c.TypeArguments {
+				b.WriteString(a.Signature())
+			}
+			b.WriteByte('>')
+		}
+	}
+	b.WriteByte(';')
+	return b.String()
+}
+
+func (t *ClassType) JavaName() string {
+	var b strings.Builder
+	if t.Package != "" {
+		b.WriteString(strings.ReplaceAll(t.Package, "/", "."))
+		b.WriteByte('.')
+	}
+	for i, c := range t.Classes {
+		if 0 < i {
+			b.WriteByte('.')
+		}
+		b.WriteString(c.Name)
+		if 0 < len(c.TypeArguments) {
+			args := make([]string, len(c.TypeArguments))
+			for i, a := range c.TypeArguments {
+				args[i] = a.JavaName()
+			}
+			b.WriteByte('<')
+			b.WriteString(strings.Join(args, ", "))
+			b.WriteByte('>')
+		}
+	}
+	return b.String()
+}
+
+func (t *ClassType) String() string { return t.JavaName() }
+
+// Wildcard is the kind of a type argument.
+type Wildcard byte
+
+const (
+	// WildcardNone is a type argument without wildcard, such as String of List<String>.
+	WildcardNone Wildcard = 0
+	// WildcardExtends is an upper-bounded wildcard, such as ? extends Number.
+	WildcardExtends Wildcard = '+'
+	// WildcardSuper is a lower-bounded wildcard, such as ? super Integer.
+	WildcardSuper Wildcard = '-'
+	// WildcardUnbounded is an unbounded wildcard, ?.
+	WildcardUnbounded Wildcard = '*'
+)
+
+// TypeArgument is a type argument of a parameterized class type.
+type TypeArgument struct {
+	Wildcard Wildcard
+	// Type is the type or the bound of the wildcard, or nil for WildcardUnbounded.
+	Type Type
+}
+
+func (a TypeArgument) Signature() string {
+	switch a.Wildcard {
+	case WildcardUnbounded:
+		return "*"
+	case WildcardExtends, WildcardSuper:
+		return string(a.Wildcard) + a.Type.Signature()
+	}
+	return a.Type.Signature()
+}
+
+func (a TypeArgument) JavaName() string {
+	switch a.Wildcard {
+	case WildcardUnbounded:
+		return "?"
+	case WildcardExtends:
+		return "? extends " + a.Type.JavaName()
+	case WildcardSuper:
+		return "? super " + a.Type.JavaName()
+	}
+	return a.Type.JavaName()
+}
+
+func (a TypeArgument) String() string { return a.JavaName() }
+
+// TypeVariable is a reference to a type parameter.
+type TypeVariable struct {
+	Name string
+}
+
+func (t *TypeVariable) Signature() string { return "T" + t.Name + ";" }
+
+func (t *TypeVariable) JavaName() string { return t.Name }
+
+func (t *TypeVariable) String() string { return t.JavaName() }
+
+// ArrayType is an array type with one dimension, whose Element may be another ArrayType.
+type ArrayType struct {
+	Element Type
+}
+
+func (t *ArrayType) Signature() string { return "[" + t.Element.Signature() }
+
+func (t *ArrayType) JavaName() string { return t.Element.JavaName() + "[]" }
+
+func (t *ArrayType) String() string { return t.JavaName() }
+
+// TypeParameter is a type parameter of a generic class or method.
+type TypeParameter struct {
+	Name string
+	// ClassBound is the class bound, or nil if the type parameter has only interface bounds.
+	ClassBound      Type
+	InterfaceBounds []Type
+}
+
+func (p TypeParameter) Signature() string {
+	var b strings.Builder
+	b.WriteString(p.Name)
+	b.WriteByte(':')
+	if p.ClassBound != nil {
+		b.WriteString(p.ClassBound.Signature())
+	}
+	for _, t := range p.InterfaceBounds {
+		b.WriteByte(':')
+		b.WriteString(t.Signature())
+	}
+	return b.String()
+}
+
+// JavaName returns the type parameter in the Java source form, such as "T extends java.lang.Comparable<T>".
+// The bound java.lang.Object is omitted.
+func (p TypeParameter) JavaName() string {
+	var bounds []string
+	if p.ClassBound != nil && !isObject(p.ClassBound) {
+		bounds = append(bounds, p.ClassBound.JavaName())
+	}
+	for _, t := range p.InterfaceBounds {
+		bounds = append(bounds, t.JavaName())
+	}
+	if len(bounds) == 0 {
+		return p.Name
+	}
+	return p.Name + " extends " + strings.Join(bounds, " & ")
+}
+
+func (p TypeParameter) String() string { return p.JavaName() }
+
+func isObject(t Type) bool {
+	c, ok := t.(*ClassType)
+	return ok && c.Package == "java/lang" && len(c.Classes) == 1 && c.Classes[0].Name == "Object" && len(c.Classes[0].TypeArguments) == 0
+}
+
+func typeParameters(b *strings.Builder, ps []TypeParameter, signature bool) {
+	if len(ps) == 0 {
+		return
+	}
+	b.WriteByte('<')
+	for i, p := range ps {
+		if signature {
+			b.WriteString(p.Signature())
+			continue
+		}
+		if 0 < i {
+			b.WriteString(", ")
+		}
+		b.WriteString(p.JavaName())
+	}
+	b.WriteByte('>')
+}
+
+// ClassSignature is the signature of a class or interface.
+type ClassSignature struct {
+	TypeParameters []TypeParameter
+	Superclass     *ClassType
+	Interfaces     []*ClassType
+}
+
+func (s *ClassSignature) Signature() string {
+	var b strings.Builder
+	typeParameters(&b, s.TypeParameters, true)
+	b.WriteString(s.Superclass.Signature())
+	for _, t := range s.Interfaces {
+		b.WriteString(t.Signature())
+	}
+	return b.String()
+}
+
+// JavaDeclaration returns the class in the Java source form with the simple name,
+// such as "Foo<T> extends Bar<T> implements java.lang.Comparable<Foo<T>>".
+// The superclass java.lang.Object is omitted.
+// The superinterfaces of an interface, whose superclass is always java.lang.Object, follow "extends".
+func (s *ClassSignature) JavaDeclaration(name string, isInterface bool) string {
+	var b strings.Builder
+	b.WriteString(name)
+	typeParameters(&b, s.TypeParameters, false)
+	if !isObject(s.Superclass) {
+		b.WriteString(" extends ")
+		b.WriteString(s.Superclass.JavaName())
+	}
+	if 0 < len(s.Interfaces) {
+		names := make([]string, len(s.Interfaces))
+		for i, t := range s.Interfaces {
+			names[i] = t.JavaName()
+		}
+		if isInterface {
+			b.WriteString(" extends ")
+		} else {
+			b.WriteString(" implements ")
+		}
+		b.WriteString(strings.Join(names, ", "))
+	}
+	return b.String()
+}
+
+// MethodSignature is the signature of a method.
+type MethodSignature struct {
+	TypeParameters []TypeParameter
+	Parameters     []Type
+	// Result is the return type, which is VoidType if the method returns no value.
+	Result Type
+	// Throws are the exception types, which are *ClassType or *TypeVariable.
+	Throws []Type
+}
+
+func (s *MethodSignature) Signature() string {
+	var b strings.Builder
+	typeParameters(&b, s.TypeParameters, true)
+	b.WriteByte('(')
+	for _, t := range s.Parameters {
+		b.WriteString(t.Signature())
+	}
+	b.WriteByte(')')
+	b.WriteString(s.Result.Signature())
+	for _, t := range s.Throws {
+		b.WriteByte('^')
+		b.WriteString(t.Signature())
+	}
+	return b.String()
+}
+
+// JavaDeclaration returns the method in the Java source form with the name,
+// such as "<T> java.util.List<T> asList(T[])".
+func (s *MethodSignature) JavaDeclaration(name string) string {
+	var b strings.Builder
+	if 0 < len(s.TypeParameters) {
+		typeParameters(&b, s.TypeParameters, false)
+		b.WriteByte(' ')
+	}
+	b.WriteString(s.Result.JavaName())
+	b.WriteByte(' ')
+	b.WriteString(name)
+
+	params := make([]string, len(s.Parameters))
+	for i, t := range s.Parameters {
+		params[i] = t.JavaName()
+	}
+	b.WriteByte('(')
+	b.WriteString(strings.Join(params, ", "))
+	b.WriteByte(')')
+
+	if 0 < len(s.Throws) {
+		throws := make([]string, len(s.Throws))
+		for i, t := range s.Throws {
+			throws[i] = t.JavaName()
+		}
+		b.WriteString(" throws ")
+		b.WriteString(strings.Join(throws, ", "))
+	}
+	return b.String()
+}
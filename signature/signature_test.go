@@ -0,0 +1,123 @@
+package signature_test
+
+import (
+	"testing"
+
+	"github.com/thara/godiva/descriptor"
+	. "github.com/thara/godiva/signature"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClass(t *testing.T) {
+	tests := []struct {
+		signature   string
+		name        string
+		isInterface bool
+		want        string
+	}{
+		{"Ljava/lang/Object;", "Plain", false, "Plain"},
+		{
+			"<E:Ljava/lang/Object;>Ljava/util/AbstractList<TE;>;Ljava/util/List<TE;>;Ljava/util/RandomAccess;",
+			"ArrayList", false,
+			"ArrayList<E> extends java.util.AbstractList<E> implements java.util.List<E>, java.util.RandomAccess",
+		},
+		{
+			"<T::Ljava/lang/Comparable<-TT;>;:Ljava/io/Serializable;U:Ljava/lang/Number;>Ljava/lang/Object;",
+			"Bounds", false,
+			"Bounds<T extends java.lang.Comparable<? super T> & java.io.Serializable, U extends java.lang.Number>",
+		},
+		{
+			"<E:Ljava/lang/Enum<TE;>;>Ljava/lang/Object;Ljava/lang/Comparable<TE;>;",
+			"Enum", false,
+			"Enum<E extends java.lang.Enum<E>> implements java.lang.Comparable<E>",
+		},
+		{
+			"<E:Ljava/lang/Object;>Ljava/lang/Object;Ljava/util/Collection<TE;>;Ljava/util/SequencedCollection<TE;>;",
+			"List", true,
+			"List<E> extends java.util.Collection<E>, java.util.SequencedCollection<E>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseClass(tt.signature)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sig.JavaDeclaration(tt.name, tt.isInterface))
+			assert.Equal(t, tt.signature, sig.Signature())
+		})
+	}
+
+	sig, err := ParseClass("<T::Ljava/lang/Runnable;>LBase;")
+	require.NoError(t, err)
+	require.Len(t, sig.TypeParameters, 1)
+	assert.Nil(t, sig.TypeParameters[0].ClassBound)
+	assert.Equal(t, "", sig.Superclass.Package)
+	assert.Equal(t, "Base", sig.Superclass.ClassName())
+
+	for _, s := range []string{"", "<>Ljava/lang/Object;", "<T>Ljava/lang/Object;", "Ljava/lang/Object", "Ljava/lang/Object;I", "TT;"} {
+		_, err := ParseClass(s)
+		assert.ErrorIs(t, err, ErrMalformed, s)
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	tests := []struct {
+		signature string
+		name      string
+		want      string
+	}{
+		{"<T:Ljava/lang/Object;>([TT;)Ljava/util/List<TT;>;", "asList", "<T> java.util.List<T> asList(T[])"},
+		{"(Ljava/util/Map<+Ljava/lang/String;*>;I)V", "putAll", "void putAll(java.util.Map<? extends java.lang.String, ?>, int)"},
+		{
+			"<X:Ljava/lang/Throwable;>(Ljava/util/function/Supplier<+TX;>;)TT;^TX;^Ljava/io/IOException;",
+			"orElseThrow",
+			"<X extends java.lang.Throwable> T orElseThrow(java.util.function.Supplier<? extends X>) throws X, java.io.IOException",
+		},
+		{"()Lpkg/Outer<TT;>.Inner<TU;>.Deep;", "inner", "pkg.Outer<T>.Inner<U>.Deep inner()"},
+		{"([[TT;J)[Ljava/util/List<*>;", "arrays", "java.util.List<?>[] arrays(T[][], long)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig, err := ParseMethod(tt.signature)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sig.JavaDeclaration(tt.name))
+			assert.Equal(t, tt.signature, sig.Signature())
+		})
+	}
+
+	sig, err := ParseMethod("()Lpkg/Outer<TT;>.Inner;")
+	require.NoError(t, err)
+	result := sig.Result.(*ClassType)
+	assert.Equal(t, "pkg/Outer$Inner", result.ClassName())
+	assert.Equal(t, []SimpleClassType{
+		{Name: "Outer", TypeArguments: []TypeArgument{{Type: &TypeVariable{Name: "T"}}}},
+		{Name: "Inner"},
+	}, result.Classes)
+
+	sig, err = ParseMethod("(I)V")
+	require.NoError(t, err)
+	assert.Equal(t, []Type{BaseType{descriptor.Int}}, sig.Parameters)
+	assert.Equal(t, VoidType{}, sig.Result)
+
+	for _, s := range []string{"", "V", "()", "(V)V", "()VV", "()V^I", "()V^", "(Ljava/util/List<>;)V", "(TT)V"} {
+		_, err := ParseMethod(s)
+		assert.ErrorIs(t, err, ErrMalformed, s)
+	}
+}
+
+func TestParseField(t *testing.T) {
+	typ, err := ParseField("Ljava/util/Map<Ljava/lang/String;Ljava/util/List<[I>;>;")
+	require.NoError(t, err)
+	assert.Equal(t, "java.util.Map<java.lang.String, java.util.List<int[]>>", typ.JavaName())
+	assert.Equal(t, "Ljava/util/Map<Ljava/lang/String;Ljava/util/List<[I>;>;", typ.Signature())
+
+	typ, err = ParseField("TT;")
+	require.NoError(t, err)
+	assert.Equal(t, &TypeVariable{Name: "T"}, typ)
+
+	for _, s := range []string{"", "I", "Ljava/lang/String;X", "L;", "Ljava/lang/String<>;"} {
+		_, err := ParseField(s)
+		assert.ErrorIs(t, err, ErrMalformed, s)
+	}
+}